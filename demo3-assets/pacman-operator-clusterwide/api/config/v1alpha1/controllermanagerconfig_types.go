@@ -0,0 +1,133 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+//+kubebuilder:object:root=true
+
+// ControllerManagerConfig is the Schema for the operator configuration file. It extends the
+// controller-runtime manager configuration with the operator settings and the game defaults
+type ControllerManagerConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ControllerManagerConfigurationSpec returns the configurations for controllers
+	cfg.ControllerManagerConfigurationSpec `json:",inline"`
+
+	// Operator holds the operator settings, each of them is overridden by the flag of the same name
+	// +optional
+	Operator OperatorSettings `json:"operator,omitempty"`
+	// GameDefaults holds the values used to render the objects of the PacmanGames that don't set them
+	// +optional
+	GameDefaults GameDefaults `json:"gameDefaults,omitempty"`
+}
+
+// OperatorSettings defines the operator settings that can be set in the configuration file
+type OperatorSettings struct {
+	// EnableClusterRBAC allows PacmanGames to run in Cluster RBAC scope
+	// +optional
+	EnableClusterRBAC *bool `json:"enableClusterRBAC,omitempty"`
+	// TracingEndpoint is the OTLP/HTTP collector endpoint the reconcile traces are exported to
+	// +optional
+	TracingEndpoint string `json:"tracingEndpoint,omitempty"`
+	// TracingSamplingRatio is the ratio of the reconcile traces sampled, between 0 and 1
+	// +optional
+	TracingSamplingRatio *float32 `json:"tracingSamplingRatio,omitempty"`
+	// APICallTimeout is the maximum duration of each Kubernetes API call made while reconciling
+	// +optional
+	APICallTimeout *metav1.Duration `json:"apiCallTimeout,omitempty"`
+	// CacheManagedObjectsOnly only caches the objects labelled as managed by the operator
+	// +optional
+	CacheManagedObjectsOnly *bool `json:"cacheManagedObjectsOnly,omitempty"`
+	// ReconcileBackoffBaseDelay is the delay before retrying a failed reconcile
+	// +optional
+	ReconcileBackoffBaseDelay *metav1.Duration `json:"reconcileBackoffBaseDelay,omitempty"`
+	// ReconcileBackoffMaxDelay is the maximum delay before retrying a failed reconcile
+	// +optional
+	ReconcileBackoffMaxDelay *metav1.Duration `json:"reconcileBackoffMaxDelay,omitempty"`
+	// KubeAPIQPS is the maximum queries per second sent to the Kubernetes API server
+	// +optional
+	KubeAPIQPS *float32 `json:"kubeAPIQPS,omitempty"`
+	// KubeAPIBurst is the maximum burst of queries sent to the Kubernetes API server
+	// +optional
+	KubeAPIBurst *int `json:"kubeAPIBurst,omitempty"`
+	// NamespaceSelector restricts the reconciled PacmanGames to the namespaces matching this label selector
+	// +optional
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+	// ResolveImageDigests deploys the Pacman images by the digest their tag resolves to, the tags are checked to
+	// exist in the registry either way
+	// +optional
+	ResolveImageDigests *bool `json:"resolveImageDigests,omitempty"`
+	// ImageVerification requires the Pacman images of some repositories to be signed before they are deployed
+	// +optional
+	ImageVerification *ImageVerification `json:"imageVerification,omitempty"`
+}
+
+// ImageVerification defines the cosign signatures required on the Pacman images
+type ImageVerification struct {
+	// Repositories whose images must be signed, including the registry host. A trailing /* matches every
+	// repository under a path, e.g. quay.io/ifont/*, and * matches every repository
+	Repositories []string `json:"repositories"`
+	// PublicKeys are the keys trusted to sign the images, an image must be signed with one of them
+	PublicKeys []PublicKey `json:"publicKeys"`
+}
+
+// PublicKey is a key trusted to sign images
+type PublicKey struct {
+	// Name identifies the key in the status of the games
+	Name string `json:"name"`
+	// Key is the PEM encoded public key, like the cosign.pub written by cosign generate-key-pair
+	Key string `json:"key"`
+}
+
+// GameDefaults defines the values used to render the objects of the PacmanGames that don't set them
+type GameDefaults struct {
+	// PacmanImageRepository is the Pacman image repository, the game appVersion is used as tag
+	// +optional
+	PacmanImageRepository string `json:"pacmanImageRepository,omitempty"`
+	// MongoImage is the image of the game database
+	// +optional
+	MongoImage string `json:"mongoImage,omitempty"`
+	// MongoExporterImage is the image of the mongo metrics exporter sidecar
+	// +optional
+	MongoExporterImage string `json:"mongoExporterImage,omitempty"`
+	// PacmanReplicas is the number of Pacman replicas of the games not setting spec.replicas
+	// +optional
+	PacmanReplicas int32 `json:"pacmanReplicas,omitempty"`
+	// PacmanResources are the compute resources of the Pacman container
+	// +optional
+	PacmanResources corev1.ResourceRequirements `json:"pacmanResources,omitempty"`
+	// MongoResources are the compute resources of the mongo container
+	// +optional
+	MongoResources corev1.ResourceRequirements `json:"mongoResources,omitempty"`
+	// PacmanServiceType is the type of the Service exposing Pacman
+	// +optional
+	PacmanServiceType corev1.ServiceType `json:"pacmanServiceType,omitempty"`
+}
+
+// Complete returns the controller-runtime manager configuration
+func (c *ControllerManagerConfig) Complete() (cfg.ControllerManagerConfigurationSpec, error) {
+	return c.ControllerManagerConfigurationSpec, nil
+}
+
+func init() {
+	SchemeBuilder.Register(&ControllerManagerConfig{})
+}
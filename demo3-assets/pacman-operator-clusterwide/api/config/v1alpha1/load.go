@@ -0,0 +1,174 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadFile reads the configuration file at a given path, unknown fields and invalid values are rejected
+func LoadFile(path string) (*ControllerManagerConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &ControllerManagerConfig{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if gvk, expected := config.GroupVersionKind(), GroupVersion.WithKind("ControllerManagerConfig"); gvk != expected {
+		return nil, fmt.Errorf("%s holds a %s, expected a %s", path, gvk, expected)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate returns an error listing every invalid value of the configuration
+func (c *ControllerManagerConfig) Validate() error {
+	var errs field.ErrorList
+	if c.CacheNamespace != "" {
+		errs = append(errs, field.Forbidden(field.NewPath("cacheNamespace"), "the watched namespaces are set through the WATCH_NAMESPACE environment variable"))
+	}
+	if c.SyncPeriod != nil && c.SyncPeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("syncPeriod"), c.SyncPeriod.Duration.String(), "must be positive"))
+	}
+	if c.Controller != nil {
+		for groupKind, concurrency := range c.Controller.GroupKindConcurrency {
+			if concurrency <= 0 {
+				errs = append(errs, field.Invalid(field.NewPath("controller", "groupKindConcurrency").Key(groupKind), concurrency, "must be positive"))
+			}
+		}
+	}
+	errs = append(errs, c.Operator.validate(field.NewPath("operator"))...)
+	errs = append(errs, c.GameDefaults.validate(field.NewPath("gameDefaults"))...)
+	return errs.ToAggregate()
+}
+
+func (s *OperatorSettings) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if s.TracingSamplingRatio != nil && (*s.TracingSamplingRatio < 0 || *s.TracingSamplingRatio > 1) {
+		errs = append(errs, field.Invalid(path.Child("tracingSamplingRatio"), *s.TracingSamplingRatio, "must be between 0 and 1"))
+	}
+	if s.APICallTimeout != nil && s.APICallTimeout.Duration < 0 {
+		errs = append(errs, field.Invalid(path.Child("apiCallTimeout"), s.APICallTimeout.Duration.String(), "must not be negative"))
+	}
+	if s.ReconcileBackoffBaseDelay != nil && s.ReconcileBackoffBaseDelay.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("reconcileBackoffBaseDelay"), s.ReconcileBackoffBaseDelay.Duration.String(), "must be positive"))
+	}
+	if s.ReconcileBackoffMaxDelay != nil && s.ReconcileBackoffMaxDelay.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("reconcileBackoffMaxDelay"), s.ReconcileBackoffMaxDelay.Duration.String(), "must be positive"))
+	}
+	if s.ReconcileBackoffBaseDelay != nil && s.ReconcileBackoffMaxDelay != nil && s.ReconcileBackoffBaseDelay.Duration > s.ReconcileBackoffMaxDelay.Duration {
+		errs = append(errs, field.Invalid(path.Child("reconcileBackoffBaseDelay"), s.ReconcileBackoffBaseDelay.Duration.String(), "must not be greater than reconcileBackoffMaxDelay"))
+	}
+	if s.KubeAPIQPS != nil && *s.KubeAPIQPS <= 0 {
+		errs = append(errs, field.Invalid(path.Child("kubeAPIQPS"), *s.KubeAPIQPS, "must be positive"))
+	}
+	if s.KubeAPIBurst != nil && *s.KubeAPIBurst <= 0 {
+		errs = append(errs, field.Invalid(path.Child("kubeAPIBurst"), *s.KubeAPIBurst, "must be positive"))
+	}
+	if _, err := labels.Parse(s.NamespaceSelector); err != nil {
+		errs = append(errs, field.Invalid(path.Child("namespaceSelector"), s.NamespaceSelector, err.Error()))
+	}
+	if s.ImageVerification != nil {
+		errs = append(errs, s.ImageVerification.validate(path.Child("imageVerification"))...)
+	}
+	return errs
+}
+
+func (v *ImageVerification) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if len(v.Repositories) == 0 {
+		errs = append(errs, field.Required(path.Child("repositories"), "at least one repository is required"))
+	}
+	for i, repository := range v.Repositories {
+		name := repository[strings.LastIndex(repository, "/")+1:]
+		if repository == "" || strings.ContainsAny(repository, " \t\n@") || strings.Contains(name, ":") {
+			errs = append(errs, field.Invalid(path.Child("repositories").Index(i), repository, "must be a repository without tag nor digest"))
+		}
+	}
+	if len(v.PublicKeys) == 0 {
+		errs = append(errs, field.Required(path.Child("publicKeys"), "at least one public key is required"))
+	}
+	names := map[string]bool{}
+	for i, key := range v.PublicKeys {
+		if key.Name == "" {
+			errs = append(errs, field.Required(path.Child("publicKeys").Index(i).Child("name"), ""))
+		} else if names[key.Name] {
+			errs = append(errs, field.Duplicate(path.Child("publicKeys").Index(i).Child("name"), key.Name))
+		}
+		names[key.Name] = true
+		block, _ := pem.Decode([]byte(key.Key))
+		if block == nil || block.Type != "PUBLIC KEY" {
+			errs = append(errs, field.Invalid(path.Child("publicKeys").Index(i).Child("key"), "", "must be a PEM encoded PUBLIC KEY"))
+		} else if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			errs = append(errs, field.Invalid(path.Child("publicKeys").Index(i).Child("key"), "", err.Error()))
+		}
+	}
+	return errs
+}
+
+func (d *GameDefaults) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for name, image := range map[string]string{
+		"pacmanImageRepository": d.PacmanImageRepository,
+		"mongoImage":            d.MongoImage,
+		"mongoExporterImage":    d.MongoExporterImage,
+	} {
+		if strings.TrimSpace(image) != image || strings.ContainsAny(image, " \t\n") {
+			errs = append(errs, field.Invalid(path.Child(name), image, "must not contain whitespaces"))
+		}
+	}
+	// The tag of the Pacman image is the game appVersion
+	if repository := d.PacmanImageRepository; strings.Contains(repository, "@") || strings.Contains(repository[strings.LastIndex(repository, "/")+1:], ":") {
+		errs = append(errs, field.Invalid(path.Child("pacmanImageRepository"), repository, "must not contain a tag nor a digest"))
+	}
+	if d.PacmanReplicas < 0 {
+		errs = append(errs, field.Invalid(path.Child("pacmanReplicas"), d.PacmanReplicas, "must not be negative"))
+	}
+	switch d.PacmanServiceType {
+	case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("pacmanServiceType"), d.PacmanServiceType,
+			[]string{string(corev1.ServiceTypeClusterIP), string(corev1.ServiceTypeNodePort), string(corev1.ServiceTypeLoadBalancer)}))
+	}
+	errs = append(errs, validateResources(path.Child("pacmanResources"), d.PacmanResources)...)
+	errs = append(errs, validateResources(path.Child("mongoResources"), d.MongoResources)...)
+	return errs
+}
+
+// validateResources checks the requests don't exceed the limits
+func validateResources(path *field.Path, resources corev1.ResourceRequirements) field.ErrorList {
+	var errs field.ErrorList
+	for name, request := range resources.Requests {
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(path.Child("requests").Key(string(name)), request.String(), "must be less than or equal to the limit "+limit.String()))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,101 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "controller_manager_config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadShippedFile(t *testing.T) {
+	config, err := LoadFile(filepath.Join("..", "..", "..", "config", "manager", "controller_manager_config.yaml"))
+	if err != nil {
+		t.Fatalf("the shipped configuration file doesn't load: %v", err)
+	}
+	if config.Operator.APICallTimeout == nil || config.Operator.APICallTimeout.Duration != 30*time.Second {
+		t.Errorf("unexpected operator.apiCallTimeout %v", config.Operator.APICallTimeout)
+	}
+	if config.GameDefaults.PacmanServiceType != "LoadBalancer" {
+		t.Errorf("unexpected gameDefaults.pacmanServiceType %q", config.GameDefaults.PacmanServiceType)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		err     string
+	}{
+		"unknown field": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  apiCallTimout: 10s\n",
+			err:     "apiCallTimout",
+		},
+		"wrong kind": {
+			content: "apiVersion: controller-runtime.sigs.k8s.io/v1alpha1\nkind: ControllerManagerConfig\n",
+			err:     "expected a config.apps.rha.lab/v1alpha1",
+		},
+		"sampling ratio over one": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  tracingSamplingRatio: 1.5\n",
+			err:     "operator.tracingSamplingRatio",
+		},
+		"backoff base over max": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  reconcileBackoffBaseDelay: 1m\n  reconcileBackoffMaxDelay: 1s\n",
+			err:     "operator.reconcileBackoffBaseDelay",
+		},
+		"tagged pacman repository": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\ngameDefaults:\n  pacmanImageRepository: quay.io/ifont/pacman-nodejs-app:latest\n",
+			err:     "gameDefaults.pacmanImageRepository",
+		},
+		"unsupported service type": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\ngameDefaults:\n  pacmanServiceType: ExternalName\n",
+			err:     "gameDefaults.pacmanServiceType",
+		},
+		"requests over limits": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\ngameDefaults:\n  mongoResources:\n    requests:\n      memory: 1Gi\n    limits:\n      memory: 512Mi\n",
+			err:     "gameDefaults.mongoResources.requests[memory]",
+		},
+		"image verification without keys": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  imageVerification:\n    repositories: [quay.io/ifont/*]\n",
+			err:     "operator.imageVerification.publicKeys",
+		},
+		"invalid verification key": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  imageVerification:\n    repositories: [quay.io/ifont/*]\n    publicKeys:\n    - name: release\n      key: not a key\n",
+			err:     "operator.imageVerification.publicKeys[0].key",
+		},
+		"tagged verification repository": {
+			content: "apiVersion: config.apps.rha.lab/v1alpha1\nkind: ControllerManagerConfig\noperator:\n  imageVerification:\n    repositories: [quay.io/ifont/pacman:v1]\n",
+			err:     "operator.imageVerification.repositories[0]",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadFile(writeConfig(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// +build !ignore_autogenerated
+
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerManagerConfig) DeepCopyInto(out *ControllerManagerConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ControllerManagerConfigurationSpec.DeepCopyInto(&out.ControllerManagerConfigurationSpec)
+	in.Operator.DeepCopyInto(&out.Operator)
+	in.GameDefaults.DeepCopyInto(&out.GameDefaults)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerManagerConfig.
+func (in *ControllerManagerConfig) DeepCopy() *ControllerManagerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerManagerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ControllerManagerConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameDefaults) DeepCopyInto(out *GameDefaults) {
+	*out = *in
+	in.PacmanResources.DeepCopyInto(&out.PacmanResources)
+	in.MongoResources.DeepCopyInto(&out.MongoResources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameDefaults.
+func (in *GameDefaults) DeepCopy() *GameDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(GameDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageVerification) DeepCopyInto(out *ImageVerification) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PublicKeys != nil {
+		in, out := &in.PublicKeys, &out.PublicKeys
+		*out = make([]PublicKey, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageVerification.
+func (in *ImageVerification) DeepCopy() *ImageVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorSettings) DeepCopyInto(out *OperatorSettings) {
+	*out = *in
+	if in.EnableClusterRBAC != nil {
+		in, out := &in.EnableClusterRBAC, &out.EnableClusterRBAC
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TracingSamplingRatio != nil {
+		in, out := &in.TracingSamplingRatio, &out.TracingSamplingRatio
+		*out = new(float32)
+		**out = **in
+	}
+	if in.APICallTimeout != nil {
+		in, out := &in.APICallTimeout, &out.APICallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CacheManagedObjectsOnly != nil {
+		in, out := &in.CacheManagedObjectsOnly, &out.CacheManagedObjectsOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReconcileBackoffBaseDelay != nil {
+		in, out := &in.ReconcileBackoffBaseDelay, &out.ReconcileBackoffBaseDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReconcileBackoffMaxDelay != nil {
+		in, out := &in.ReconcileBackoffMaxDelay, &out.ReconcileBackoffMaxDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.KubeAPIQPS != nil {
+		in, out := &in.KubeAPIQPS, &out.KubeAPIQPS
+		*out = new(float32)
+		**out = **in
+	}
+	if in.KubeAPIBurst != nil {
+		in, out := &in.KubeAPIBurst, &out.KubeAPIBurst
+		*out = new(int)
+		**out = **in
+	}
+	if in.ResolveImageDigests != nil {
+		in, out := &in.ResolveImageDigests, &out.ResolveImageDigests
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ImageVerification != nil {
+		in, out := &in.ImageVerification, &out.ImageVerification
+		*out = new(ImageVerification)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorSettings.
+func (in *OperatorSettings) DeepCopy() *OperatorSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicKey) DeepCopyInto(out *PublicKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicKey.
+func (in *PublicKey) DeepCopy() *PublicKey {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicKey)
+	in.DeepCopyInto(out)
+	return out
+}
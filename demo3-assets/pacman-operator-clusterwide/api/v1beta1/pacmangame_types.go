@@ -17,6 +17,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,12 +25,189 @@ import (
 type PacmanGameSpec struct {
 	Replicas   int32  `json:"replicas"`
 	AppVersion string `json:"appVersion,omitempty"`
+	// RBAC configures the permissions granted to the Pacman ServiceAccount
+	// +optional
+	RBAC PacmanGameRBAC `json:"rbac,omitempty"`
+	// ManagementPolicy defines whether the operator writes the objects of the game (Managed), only
+	// reports how they drifted from the desired state (ObserveOnly) or leaves them alone (Unmanaged)
+	// +kubebuilder:default=Managed
+	// +optional
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+	// Suspend stops the reconciliation of the game objects until it's set back to false
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Monitoring configures the Prometheus and Grafana objects created for the game
+	// +optional
+	Monitoring PacmanGameMonitoring `json:"monitoring,omitempty"`
+	// Mongo configures the game database
+	// +optional
+	Mongo PacmanGameMongo `json:"mongo,omitempty"`
+	// Pacman configures the game frontend
+	// +optional
+	Pacman PacmanGamePacman `json:"pacman,omitempty"`
+	// ImagePullSecrets are the secrets used to pull the game images, they are set on both Deployments
+	// and on the game ServiceAccount
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// PacmanGamePacman defines the frontend configuration of a PacmanGame
+type PacmanGamePacman struct {
+	// Image of the Pacman container, it defaults to the operator Pacman image with appVersion as tag
+	// +optional
+	Image PacmanImage `json:"image,omitempty"`
+}
+
+// PacmanImage defines the image of the Pacman container. Tag and digest can't be both set
+type PacmanImage struct {
+	// Repository of the image, e.g. quay.io/myteam/pacman-fork, defaults to the operator Pacman image repository
+	// +kubebuilder:validation:Pattern=`^([^\s@/]+/)*[^\s@:/]+$`
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// Tag of the image, defaults to appVersion
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// Digest pins the image, e.g. sha256:0d1f...
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9]*([-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// PullPolicy of the image
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+	// RefreshInterval opts into re-resolving the image tag to a digest at this interval, so a moved tag is
+	// rolled out. When unset the digest resolved first is kept until the image reference changes
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// PacmanGameMongo defines the database configuration of a PacmanGame
+type PacmanGameMongo struct {
+	// Exporter configures the mongo metrics exporter sidecar
+	// +optional
+	Exporter MongoExporter `json:"exporter,omitempty"`
+}
+
+// MongoExporter defines the mongo metrics exporter sidecar configuration
+type MongoExporter struct {
+	// Enabled adds a metrics exporter sidecar to the mongo pod, exposed through the metrics port of the mongo Service
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Image of the exporter, defaults to the mongo exporter image configured in the operator
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// PacmanGameMonitoring defines the monitoring configuration of a PacmanGame
+type PacmanGameMonitoring struct {
+	// Enabled creates ServiceMonitors, a PrometheusRule and a Grafana dashboard ConfigMap for the game.
+	// They are skipped when the Prometheus Operator CRDs are not installed
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval between scrapes of the game endpoints
+	// +kubebuilder:default="30s"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// ManagementPolicy defines how the operator manages the objects of a PacmanGame
+// +kubebuilder:validation:Enum=Managed;ObserveOnly;Unmanaged
+type ManagementPolicy string
+
+const (
+	// ManagementPolicyManaged creates the objects and corrects any drift on them
+	ManagementPolicyManaged ManagementPolicy = "Managed"
+	// ManagementPolicyObserveOnly reports the drift on the objects without writing them
+	ManagementPolicyObserveOnly ManagementPolicy = "ObserveOnly"
+	// ManagementPolicyUnmanaged doesn't read nor write the objects
+	ManagementPolicyUnmanaged ManagementPolicy = "Unmanaged"
+)
+
+// RBACScope defines where the permissions for the Pacman ServiceAccount are granted
+// +kubebuilder:validation:Enum=Namespace;Cluster
+type RBACScope string
+
+const (
+	// RBACScopeNamespace grants access to the pods in the PacmanGame namespace through a Role
+	RBACScopeNamespace RBACScope = "Namespace"
+	// RBACScopeCluster grants access to pods and nodes through a ClusterRole
+	RBACScopeCluster RBACScope = "Cluster"
+)
+
+// PacmanGameRBAC defines the RBAC configuration of a PacmanGame
+type PacmanGameRBAC struct {
+	// Scope of the permissions granted to the Pacman ServiceAccount. In Namespace scope
+	// the game can't read node information, so the node info feature is disabled
+	// +kubebuilder:default=Cluster
+	// +optional
+	Scope RBACScope `json:"scope,omitempty"`
 }
 
 // PacmanGameStatus defines the observed state of PacmanGame
 type PacmanGameStatus struct {
 	AppPods    []string           `json:"appPods"`
 	Conditions []metav1.Condition `json:"conditions"`
+	// ObservedGeneration is the PacmanGame generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// FirstReadyTime is the time the PacmanGame became ready for the first time
+	// +optional
+	FirstReadyTime *metav1.Time `json:"firstReadyTime,omitempty"`
+	// Pods describes the state of the Pacman pods
+	// +optional
+	Pods []PacmanGamePod `json:"pods,omitempty"`
+	// Drift lists the managed objects that differ from the desired state, only reported in ObserveOnly
+	// +optional
+	Drift []DriftedObject `json:"drift,omitempty"`
+	// ConfigGeneration is the generation of the PacmanOperatorConfig the game objects were rendered with,
+	// unset when they were rendered with the operator defaults
+	// +optional
+	ConfigGeneration int64 `json:"configGeneration,omitempty"`
+	// AppImage is the Pacman image the game is deployed with
+	// +optional
+	AppImage *AppImageStatus `json:"appImage,omitempty"`
+}
+
+// AppImageStatus describes the Pacman image a game is deployed with
+type AppImageStatus struct {
+	// Reference is the requested image, e.g. quay.io/ifont/pacman-nodejs-app:v0.0.1
+	Reference string `json:"reference"`
+	// Digest is the digest the reference resolved to, unset when the registry couldn't be queried
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// Image is the image set on the Pacman containers
+	Image string `json:"image"`
+	// ResolvedTime is the time the digest was resolved
+	// +optional
+	ResolvedTime *metav1.Time `json:"resolvedTime,omitempty"`
+	// SignedBy is the name of the key the image signature was verified with, unset when no signature is required
+	// +optional
+	SignedBy string `json:"signedBy,omitempty"`
+}
+
+// PacmanGamePod describes the state of a Pacman pod
+type PacmanGamePod struct {
+	Name string `json:"name"`
+	// +optional
+	Phase corev1.PodPhase `json:"phase,omitempty"`
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	Ready    bool   `json:"ready"`
+	// Restarts is the sum of the restarts of the pod containers
+	Restarts int32 `json:"restarts"`
+}
+
+// DriftedObject summarises how a managed object differs from the desired state
+type DriftedObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Missing is true when the object doesn't exist
+	// +optional
+	Missing bool `json:"missing,omitempty"`
+	// Fields lists the paths of the fields that differ from the desired state
+	// +optional
+	Fields []string `json:"fields,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -63,6 +241,112 @@ const (
 
 	ConditionTypePacmanGameDeploymentNotReady string = "PacmanGameDeploymentNotReady"
 
-	// ConditionTypeReady indicates if the Reverse Words Deployment is ready
+	// ConditionTypeReady indicates if all the PacmanGame components are ready
 	ConditionTypeReady string = "Ready"
+
+	// ConditionTypeDatabaseReady indicates if the Mongo Deployment and Service are ready
+	ConditionTypeDatabaseReady string = "DatabaseReady"
+
+	// ConditionTypeFrontendReady indicates if the Pacman Deployment is ready
+	ConditionTypeFrontendReady string = "FrontendReady"
+
+	// ConditionTypeExposureReady indicates if the Pacman Service is ready
+	ConditionTypeExposureReady string = "ExposureReady"
+
+	// ConditionTypeRBACReady indicates if the Pacman ServiceAccount and its permissions are ready
+	ConditionTypeRBACReady string = "RBACReady"
+
+	// ConditionTypeMonitoringReady indicates if the monitoring objects are ready
+	ConditionTypeMonitoringReady string = "MonitoringReady"
+
+	// ConditionTypeProgressing indicates if any PacmanGame component is being rolled out
+	ConditionTypeProgressing string = "Progressing"
+
+	// ConditionTypeDegraded indicates if any PacmanGame component failed to reconcile
+	ConditionTypeDegraded string = "Degraded"
+
+	// ConditionTypeSuspended indicates if the reconciliation of the PacmanGame is suspended
+	ConditionTypeSuspended string = "Suspended"
+
+	// ConditionTypeDrifted indicates if any managed object differs from the desired state
+	ConditionTypeDrifted string = "Drifted"
+
+	// ConditionTypeInvalidAppVersion indicates if the requested Pacman image tag doesn't exist in the registry,
+	// the Pacman Deployment keeps running its current version until it's fixed
+	ConditionTypeInvalidAppVersion string = "InvalidAppVersion"
+
+	// ConditionTypeImageRejected indicates if the Pacman image is refused by the image verification policy,
+	// the Pacman Deployment keeps running its current version until a signed image is requested
+	ConditionTypeImageRejected string = "ImageRejected"
+)
+
+// Condition reasons
+const (
+	// ReasonReconcileFailed is used when a component could not be reconciled
+	ReasonReconcileFailed string = "ReconcileFailed"
+
+	// ReasonWaitingForDependency is used when a component waits for the components it depends on to be ready
+	ReasonWaitingForDependency string = "WaitingForDependency"
+
+	// ReasonDeploymentAvailable is used when all the replicas of a Deployment are ready
+	ReasonDeploymentAvailable string = "DeploymentAvailable"
+
+	// ReasonDeploymentProgressing is used while a Deployment is rolling out its replicas
+	ReasonDeploymentProgressing string = "DeploymentProgressing"
+
+	// ReasonServiceReady is used when a Service is ready to receive traffic
+	ReasonServiceReady string = "ServiceReady"
+
+	// ReasonLoadBalancerPending is used while a LoadBalancer Service waits for an address
+	ReasonLoadBalancerPending string = "LoadBalancerPending"
+
+	// ReasonRBACConfigured is used when the ServiceAccount and its permissions are in place
+	ReasonRBACConfigured string = "RBACConfigured"
+
+	// ReasonMonitoringConfigured is used when the monitoring objects are in place
+	ReasonMonitoringConfigured string = "MonitoringConfigured"
+
+	// ReasonMonitoringDisabled is used when monitoring is not enabled for the PacmanGame
+	ReasonMonitoringDisabled string = "MonitoringDisabled"
+
+	// ReasonMonitoringUnavailable is used when the Prometheus Operator CRDs are not installed
+	ReasonMonitoringUnavailable string = "MonitoringUnavailable"
+
+	// ReasonNoMetricsSource is used when monitoring is enabled but no ServiceMonitor can be created because no
+	// container of the game serves metrics
+	ReasonNoMetricsSource string = "NoMetricsSource"
+
+	// ReasonAllComponentsReady is used when every component of the PacmanGame is ready
+	ReasonAllComponentsReady string = "AllComponentsReady"
+
+	// ReasonComponentsNotReady is used while some component of the PacmanGame is not ready
+	ReasonComponentsNotReady string = "ComponentsNotReady"
+
+	// ReasonInvalidSpec is used when the PacmanGame spec is invalid, its objects are not rendered until it's fixed
+	ReasonInvalidSpec string = "InvalidSpec"
+
+	// ReasonComponentsHealthy is used when no component of the PacmanGame failed to reconcile
+	ReasonComponentsHealthy string = "ComponentsHealthy"
+
+	// ReasonRolloutBlocked is used when the requested Pacman image is missing or rejected, the Pacman Deployment
+	// keeps running its current version
+	ReasonRolloutBlocked string = "RolloutBlocked"
+
+	// ReasonImageTagNotFound is used when the requested Pacman image tag doesn't exist in the registry
+	ReasonImageTagNotFound string = "ImageTagNotFound"
+
+	// ReasonImageFound is used when the requested Pacman image exists in the registry
+	ReasonImageFound string = "ImageFound"
+
+	// ReasonImageNotChecked is used when the Pacman image is deployed without querying the registry
+	ReasonImageNotChecked string = "ImageNotChecked"
+
+	// ReasonSignatureVerified is used when the Pacman image is signed with a trusted key
+	ReasonSignatureVerified string = "SignatureVerified"
+
+	// ReasonSignatureNotRequired is used when the image verification policy doesn't cover the Pacman image
+	ReasonSignatureNotRequired string = "SignatureNotRequired"
+
+	// ReasonSignatureVerificationFailed is used when the Pacman image has no valid signature made with a trusted key
+	ReasonSignatureVerificationFailed string = "SignatureVerificationFailed"
 )
@@ -0,0 +1,80 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PacmanOperatorConfigName is the name of the PacmanOperatorConfig read by the operator, any other is ignored
+const PacmanOperatorConfigName = "cluster"
+
+// PacmanOperatorConfigSpec defines the defaults used to render the objects of the PacmanGames that don't set them.
+// The unset fields keep the defaults of the operator configuration file
+type PacmanOperatorConfigSpec struct {
+	// PacmanImageRepository is the Pacman image repository without tag, the game appVersion is used as tag
+	// +kubebuilder:validation:Pattern=`^([^\s@/]+/)*[^\s@:/]+$`
+	// +optional
+	PacmanImageRepository string `json:"pacmanImageRepository,omitempty"`
+	// MongoImage is the image of the game database
+	// +optional
+	MongoImage string `json:"mongoImage,omitempty"`
+	// MongoExporterImage is the image of the mongo metrics exporter sidecar
+	// +optional
+	MongoExporterImage string `json:"mongoExporterImage,omitempty"`
+	// PacmanReplicas is the number of Pacman replicas of the games not setting spec.replicas
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PacmanReplicas int32 `json:"pacmanReplicas,omitempty"`
+	// PacmanResources are the compute resources of the Pacman container
+	// +optional
+	PacmanResources corev1.ResourceRequirements `json:"pacmanResources,omitempty"`
+	// MongoResources are the compute resources of the mongo container
+	// +optional
+	MongoResources corev1.ResourceRequirements `json:"mongoResources,omitempty"`
+	// PacmanServiceType is the type of the Service exposing Pacman
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	PacmanServiceType corev1.ServiceType `json:"pacmanServiceType,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PacmanOperatorConfig is the Schema for the PacmanOperatorConfigs API. It holds the cluster wide
+// defaults of the PacmanGames, only the one named cluster is read by the operator
+type PacmanOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PacmanOperatorConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PacmanOperatorConfigList contains a list of PacmanOperatorConfig
+type PacmanOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PacmanOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PacmanOperatorConfig{}, &PacmanOperatorConfigList{})
+}
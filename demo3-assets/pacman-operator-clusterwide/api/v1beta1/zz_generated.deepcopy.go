@@ -21,16 +21,71 @@ limitations under the License.
 package v1beta1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppImageStatus) DeepCopyInto(out *AppImageStatus) {
+	*out = *in
+	if in.ResolvedTime != nil {
+		in, out := &in.ResolvedTime, &out.ResolvedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppImageStatus.
+func (in *AppImageStatus) DeepCopy() *AppImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftedObject) DeepCopyInto(out *DriftedObject) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftedObject.
+func (in *DriftedObject) DeepCopy() *DriftedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoExporter) DeepCopyInto(out *MongoExporter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoExporter.
+func (in *MongoExporter) DeepCopy() *MongoExporter {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoExporter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PacmanGame) DeepCopyInto(out *PacmanGame) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -84,9 +139,95 @@ func (in *PacmanGameList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanGameMongo) DeepCopyInto(out *PacmanGameMongo) {
+	*out = *in
+	out.Exporter = in.Exporter
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGameMongo.
+func (in *PacmanGameMongo) DeepCopy() *PacmanGameMongo {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanGameMongo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanGameMonitoring) DeepCopyInto(out *PacmanGameMonitoring) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGameMonitoring.
+func (in *PacmanGameMonitoring) DeepCopy() *PacmanGameMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanGameMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanGamePacman) DeepCopyInto(out *PacmanGamePacman) {
+	*out = *in
+	in.Image.DeepCopyInto(&out.Image)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGamePacman.
+func (in *PacmanGamePacman) DeepCopy() *PacmanGamePacman {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanGamePacman)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanGamePod) DeepCopyInto(out *PacmanGamePod) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGamePod.
+func (in *PacmanGamePod) DeepCopy() *PacmanGamePod {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanGamePod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanGameRBAC) DeepCopyInto(out *PacmanGameRBAC) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGameRBAC.
+func (in *PacmanGameRBAC) DeepCopy() *PacmanGameRBAC {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanGameRBAC)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PacmanGameSpec) DeepCopyInto(out *PacmanGameSpec) {
 	*out = *in
+	out.RBAC = in.RBAC
+	out.Monitoring = in.Monitoring
+	out.Mongo = in.Mongo
+	in.Pacman.DeepCopyInto(&out.Pacman)
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGameSpec.
@@ -109,11 +250,32 @@ func (in *PacmanGameStatus) DeepCopyInto(out *PacmanGameStatus) {
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FirstReadyTime != nil {
+		in, out := &in.FirstReadyTime, &out.FirstReadyTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]PacmanGamePod, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]DriftedObject, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AppImage != nil {
+		in, out := &in.AppImage, &out.AppImage
+		*out = new(AppImageStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanGameStatus.
@@ -125,3 +287,98 @@ func (in *PacmanGameStatus) DeepCopy() *PacmanGameStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanImage) DeepCopyInto(out *PacmanImage) {
+	*out = *in
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanImage.
+func (in *PacmanImage) DeepCopy() *PacmanImage {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanOperatorConfig) DeepCopyInto(out *PacmanOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanOperatorConfig.
+func (in *PacmanOperatorConfig) DeepCopy() *PacmanOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PacmanOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanOperatorConfigList) DeepCopyInto(out *PacmanOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PacmanOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanOperatorConfigList.
+func (in *PacmanOperatorConfigList) DeepCopy() *PacmanOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PacmanOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PacmanOperatorConfigSpec) DeepCopyInto(out *PacmanOperatorConfigSpec) {
+	*out = *in
+	in.PacmanResources.DeepCopyInto(&out.PacmanResources)
+	in.MongoResources.DeepCopyInto(&out.MongoResources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PacmanOperatorConfigSpec.
+func (in *PacmanOperatorConfigSpec) DeepCopy() *PacmanOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PacmanOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,256 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/mvazquezc/pacman-operator/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// reconcileStep reconciles one of the objects of a PacmanGame
+type reconcileStep func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error)
+
+// componentStep is a named reconcile step of a component
+type componentStep struct {
+	name      string
+	reconcile reconcileStep
+}
+
+// component is a part of a PacmanGame reporting its state through a condition.
+// A component is only reconciled once the conditions of the components it depends on are true
+type component struct {
+	conditionType string
+	dependsOn     []string
+	steps         []componentStep
+	// waitingStep refreshes the status of the component while it waits for its dependencies, optional
+	waitingStep *componentStep
+}
+
+// components returns the dependency graph of the components of a PacmanGame
+func (r *PacmanGameReconciler) components() []component {
+	return []component{
+		{
+			conditionType: appsv1beta1.ConditionTypeDatabaseReady,
+			steps: []componentStep{
+				{name: "MongoDeployment", reconcile: r.reconcileMongoDeployment},
+				{name: "MongoService", reconcile: r.reconcileMongoService},
+			},
+		},
+		{
+			conditionType: appsv1beta1.ConditionTypeRBACReady,
+			steps: []componentStep{
+				{name: "PacmanServiceAccount", reconcile: r.reconcilePacmanServiceAccount},
+				{name: "PacmanRBAC", reconcile: r.reconcilePacmanRBAC},
+			},
+		},
+		{
+			conditionType: appsv1beta1.ConditionTypeExposureReady,
+			steps: []componentStep{
+				{name: "PacmanService", reconcile: r.reconcilePacmanService},
+			},
+		},
+		{
+			conditionType: appsv1beta1.ConditionTypeMonitoringReady,
+			steps: []componentStep{
+				{name: "Monitoring", reconcile: r.reconcileMonitoring},
+			},
+		},
+		{
+			// Pacman can't start without its database, and its pods run with the Pacman ServiceAccount
+			conditionType: appsv1beta1.ConditionTypeFrontendReady,
+			dependsOn:     []string{appsv1beta1.ConditionTypeDatabaseReady, appsv1beta1.ConditionTypeRBACReady},
+			steps: []componentStep{
+				{name: "PacmanDeployment", reconcile: r.reconcilePacmanDeployment},
+			},
+			waitingStep: &componentStep{name: "PacmanPodStatus", reconcile: r.reconcilePacmanPodStatus},
+		},
+	}
+}
+
+// componentOutcome is the result of reconciling a component on its own copy of the CR
+type componentOutcome struct {
+	cr     *appsv1beta1.PacmanGame
+	result ctrl.Result
+	err    error
+}
+
+// runComponents reconciles the components of a PacmanGame in waves. Each wave runs in parallel the components
+// whose dependencies have been processed, the components with a dependency that is not ready are marked
+// as waiting for it. Every component renders its objects with the same given defaults.
+// It returns the errors of the failed components
+func (r *PacmanGameReconciler) runComponents(ctx context.Context, cr *appsv1beta1.PacmanGame, components []component, defaults Defaults, log logr.Logger) (ctrl.Result, []error) {
+	processed := map[string]bool{}
+	result := ctrl.Result{}
+	var errs []error
+	for len(processed) < len(components) {
+		var wave []component
+		for _, c := range components {
+			if !processed[c.conditionType] && allProcessed(processed, c.dependsOn) {
+				wave = append(wave, c)
+			}
+		}
+		if len(wave) == 0 {
+			// Only possible with a dependency cycle or a dependency on an unknown component
+			return result, append(errs, fmt.Errorf("unable to resolve the dependencies of the PacmanGame components"))
+		}
+
+		var running []component
+		for _, c := range wave {
+			processed[c.conditionType] = true
+			if waitingFor := notReadyConditions(cr, c.dependsOn); len(waitingFor) > 0 {
+				log.Info("Component waiting for its dependencies", "Component", c.conditionType, "WaitingFor", waitingFor)
+				setCondition(cr, c.conditionType, metav1.ConditionFalse, appsv1beta1.ReasonWaitingForDependency, "Blocked on "+strings.Join(waitingFor, ", "))
+				if c.waitingStep != nil {
+					if _, err := r.runStep(ctx, cr, c.waitingStep.name, c.waitingStep.reconcile, defaults, log); err != nil {
+						errs = append(errs, fmt.Errorf("failed to reconcile %s: %w", c.waitingStep.name, err))
+					}
+				}
+				continue
+			}
+			running = append(running, c)
+		}
+
+		// Every component works on its own copy of the CR, their status changes are merged once they finish
+		base := cr.DeepCopy()
+		outcomes := make([]componentOutcome, len(running))
+		var wg sync.WaitGroup
+		for i, c := range running {
+			wg.Add(1)
+			go func(i int, c component) {
+				defer wg.Done()
+				componentCR := base.DeepCopy()
+				result, err := r.runComponent(ctx, componentCR, c, defaults, log)
+				outcomes[i] = componentOutcome{cr: componentCR, result: result, err: err}
+			}(i, c)
+		}
+		wg.Wait()
+
+		for _, outcome := range outcomes {
+			mergeComponentStatus(cr, base, outcome.cr)
+			result = mergeResults(result, outcome.result)
+			if outcome.err != nil {
+				errs = append(errs, outcome.err)
+			}
+		}
+	}
+	return result, errs
+}
+
+// runComponent runs the steps of a component in order, stopping at the first failure
+func (r *PacmanGameReconciler) runComponent(ctx context.Context, cr *appsv1beta1.PacmanGame, c component, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+	result := ctrl.Result{}
+	for _, step := range c.steps {
+		stepResult, err := r.runStep(ctx, cr, step.name, step.reconcile, defaults, log)
+		if err != nil {
+			return result, fmt.Errorf("failed to reconcile %s: %w", step.name, r.reportFailure(cr, c.conditionType, step.name, err, log))
+		}
+		result = mergeResults(result, stepResult)
+	}
+	return result, nil
+}
+
+// runStep runs a reconcile step in its own span
+func (r *PacmanGameReconciler) runStep(ctx context.Context, cr *appsv1beta1.PacmanGame, name string, step reconcileStep, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+	ctx, span := tracing.StartSpan(ctx, "PacmanGame.Reconcile"+name,
+		attribute.String("pacmangame.namespace", cr.Namespace),
+		attribute.String("pacmangame.name", cr.Name),
+		attribute.Int64("pacmangame.generation", cr.Generation))
+	defer span.End()
+	result, err := step(ctx, cr, defaults, log)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+// allProcessed returns true if all the given components have been processed
+func allProcessed(processed map[string]bool, conditionTypes []string) bool {
+	for _, conditionType := range conditionTypes {
+		if !processed[conditionType] {
+			return false
+		}
+	}
+	return true
+}
+
+// notReadyConditions returns the given conditions that are not true on a given CR
+func notReadyConditions(cr *appsv1beta1.PacmanGame, conditionTypes []string) []string {
+	var notReady []string
+	for _, conditionType := range conditionTypes {
+		if !meta.IsStatusConditionTrue(cr.Status.Conditions, conditionType) {
+			notReady = append(notReady, conditionType)
+		}
+	}
+	return notReady
+}
+
+// mergeComponentStatus copies into cr the status changes a component made on its copy of base
+func mergeComponentStatus(cr *appsv1beta1.PacmanGame, base *appsv1beta1.PacmanGame, updated *appsv1beta1.PacmanGame) {
+	for _, condition := range updated.Status.Conditions {
+		if previous := meta.FindStatusCondition(base.Status.Conditions, condition.Type); previous == nil || !reflect.DeepEqual(*previous, condition) {
+			meta.SetStatusCondition(&cr.Status.Conditions, condition)
+		}
+	}
+	for _, drift := range updated.Status.Drift {
+		drift := drift
+		if previous := findDrift(base.Status.Drift, drift.Kind, drift.Name); previous == nil || !reflect.DeepEqual(*previous, drift) {
+			recordDrift(cr, drift.Kind, drift.Name, &drift)
+		}
+	}
+	for _, drift := range base.Status.Drift {
+		if findDrift(updated.Status.Drift, drift.Kind, drift.Name) == nil {
+			recordDrift(cr, drift.Kind, drift.Name, nil)
+		}
+	}
+	if !reflect.DeepEqual(base.Status.AppPods, updated.Status.AppPods) {
+		cr.Status.AppPods = updated.Status.AppPods
+	}
+	if !reflect.DeepEqual(base.Status.Pods, updated.Status.Pods) {
+		cr.Status.Pods = updated.Status.Pods
+	}
+	if !reflect.DeepEqual(base.Status.AppImage, updated.Status.AppImage) {
+		cr.Status.AppImage = updated.Status.AppImage
+	}
+}
+
+// findDrift returns the drift entry of a given object, or nil if there is none
+func findDrift(drift []appsv1beta1.DriftedObject, kind string, name string) *appsv1beta1.DriftedObject {
+	for i := range drift {
+		if drift[i].Kind == kind && drift[i].Name == name {
+			return &drift[i]
+		}
+	}
+	return nil
+}
+
+// mergeResults returns a result requeueing as soon as any of the given results
+func mergeResults(a ctrl.Result, b ctrl.Result) ctrl.Result {
+	merged := ctrl.Result{Requeue: a.Requeue || b.Requeue, RequeueAfter: a.RequeueAfter}
+	if b.RequeueAfter > 0 && (merged.RequeueAfter == 0 || b.RequeueAfter < merged.RequeueAfter) {
+		merged.RequeueAfter = b.RequeueAfter
+	}
+	return merged
+}
@@ -0,0 +1,312 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setConditionStep returns a reconcile step setting a condition with a given status
+func setConditionStep(conditionType string, status metav1.ConditionStatus) reconcileStep {
+	return func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+		setCondition(cr, conditionType, status, appsv1beta1.ReasonDeploymentProgressing, conditionType+" set by the test")
+		return ctrl.Result{}, nil
+	}
+}
+
+func TestRunComponentsRefreshesPodsWhileWaiting(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	// The pods reported by a previous reconcile are gone, a new one is starting
+	cr.Status.AppPods = []string{"pacman-game-old"}
+	cr.Status.Pods = []appsv1beta1.PacmanGamePod{{Name: "pacman-game-old"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pacman-game-new", Namespace: "pacman", Labels: labelsForCR(cr, "pacman")},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().WithObjects(pod).Build()}
+	deployed := false
+	components := []component{
+		{
+			conditionType: appsv1beta1.ConditionTypeDatabaseReady,
+			steps:         []componentStep{{name: "Database", reconcile: setConditionStep(appsv1beta1.ConditionTypeDatabaseReady, metav1.ConditionFalse)}},
+		},
+		{
+			conditionType: appsv1beta1.ConditionTypeFrontendReady,
+			dependsOn:     []string{appsv1beta1.ConditionTypeDatabaseReady},
+			steps: []componentStep{{name: "Frontend", reconcile: func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+				deployed = true
+				return ctrl.Result{}, nil
+			}}},
+			waitingStep: &componentStep{name: "PacmanPodStatus", reconcile: r.reconcilePacmanPodStatus},
+		},
+	}
+
+	if _, errs := r.runComponents(context.Background(), cr, components, Defaults{}, logr.Discard()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if deployed {
+		t.Errorf("expected the frontend not to be reconciled while the database isn't ready")
+	}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeFrontendReady)
+	if condition == nil || condition.Reason != appsv1beta1.ReasonWaitingForDependency {
+		t.Errorf("expected the frontend to wait for the database, got %+v", condition)
+	}
+	if !reflect.DeepEqual(cr.Status.AppPods, []string{"pacman-game-new"}) || len(cr.Status.Pods) != 1 || cr.Status.Pods[0].Name != "pacman-game-new" {
+		t.Errorf("expected the pod status to be refreshed, got %v and %+v", cr.Status.AppPods, cr.Status.Pods)
+	}
+}
+
+// stepRecorder records the waves the reconcile steps of a test ran in
+type stepRecorder struct {
+	sync.Mutex
+	waves map[string]int
+}
+
+// step returns a reconcile step setting a condition with a given status and returning a given result.
+// It's recorded in the wave following the ones of the steps it depends on
+func (s *stepRecorder) step(conditionType string, status metav1.ConditionStatus, result ctrl.Result, dependsOn ...string) reconcileStep {
+	return func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+		s.Lock()
+		defer s.Unlock()
+		wave := 0
+		for _, dependency := range dependsOn {
+			if s.waves[dependency] >= wave {
+				wave = s.waves[dependency] + 1
+			}
+		}
+		s.waves[conditionType] = wave
+		setCondition(cr, conditionType, status, appsv1beta1.ReasonDeploymentAvailable, conditionType+" set by the test")
+		return result, nil
+	}
+}
+
+// testComponent returns a component with a single step recorded by a given recorder
+func testComponent(recorder *stepRecorder, conditionType string, status metav1.ConditionStatus, result ctrl.Result, dependsOn ...string) component {
+	return component{
+		conditionType: conditionType,
+		dependsOn:     dependsOn,
+		steps:         []componentStep{{name: conditionType, reconcile: recorder.step(conditionType, status, result, dependsOn...)}},
+	}
+}
+
+func TestRunComponentsInWaves(t *testing.T) {
+	recorder := &stepRecorder{waves: map[string]int{}}
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	// Each component of the first wave writes its own part of the status on its copy of the CR
+	withImage := component{
+		conditionType: appsv1beta1.ConditionTypeRBACReady,
+		steps: []componentStep{{name: "Image", reconcile: func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+			cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: "quay.io/ifont/pacman-nodejs-app:v0.0.1"}
+			return recorder.step(appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, ctrl.Result{})(ctx, cr, defaults, log)
+		}}},
+	}
+	withDrift := component{
+		conditionType: appsv1beta1.ConditionTypeExposureReady,
+		steps: []componentStep{{name: "Drift", reconcile: func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+			recordDrift(cr, "Service", "pacman-game", &appsv1beta1.DriftedObject{Kind: "Service", Name: "pacman-game", Missing: true})
+			return recorder.step(appsv1beta1.ConditionTypeExposureReady, metav1.ConditionTrue, ctrl.Result{RequeueAfter: 10 * time.Second})(ctx, cr, defaults, log)
+		}}},
+	}
+	components := []component{
+		// Listed before its dependencies, the order of the list doesn't matter
+		testComponent(recorder, appsv1beta1.ConditionTypeFrontendReady, metav1.ConditionTrue, ctrl.Result{RequeueAfter: 5 * time.Second},
+			appsv1beta1.ConditionTypeDatabaseReady, appsv1beta1.ConditionTypeRBACReady),
+		testComponent(recorder, appsv1beta1.ConditionTypeDatabaseReady, metav1.ConditionTrue, ctrl.Result{}),
+		withImage,
+		withDrift,
+		testComponent(recorder, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionTrue, ctrl.Result{RequeueAfter: time.Minute},
+			appsv1beta1.ConditionTypeFrontendReady),
+	}
+
+	result, errs := (&PacmanGameReconciler{}).runComponents(context.Background(), cr, components, Defaults{}, logr.Discard())
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	wantWaves := map[string]int{
+		appsv1beta1.ConditionTypeDatabaseReady:   0,
+		appsv1beta1.ConditionTypeRBACReady:       0,
+		appsv1beta1.ConditionTypeExposureReady:   0,
+		appsv1beta1.ConditionTypeFrontendReady:   1,
+		appsv1beta1.ConditionTypeMonitoringReady: 2,
+	}
+	if !reflect.DeepEqual(recorder.waves, wantWaves) {
+		t.Errorf("expected the waves %v, got %v", wantWaves, recorder.waves)
+	}
+	// The status changes of the parallel components are all merged
+	for conditionType := range wantWaves {
+		if !meta.IsStatusConditionTrue(cr.Status.Conditions, conditionType) {
+			t.Errorf("expected %s to be true, got %+v", conditionType, cr.Status.Conditions)
+		}
+	}
+	if cr.Status.AppImage == nil || len(cr.Status.Drift) != 1 {
+		t.Errorf("expected the image and the drift to be merged, got %+v and %+v", cr.Status.AppImage, cr.Status.Drift)
+	}
+	// The game is requeued as soon as the first component asks for it
+	if result.RequeueAfter != 5*time.Second {
+		t.Errorf("expected to be requeued after 5s, got %+v", result)
+	}
+}
+
+func TestRunComponentsBlocksDependents(t *testing.T) {
+	recorder := &stepRecorder{waves: map[string]int{}}
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	components := []component{
+		testComponent(recorder, appsv1beta1.ConditionTypeDatabaseReady, metav1.ConditionFalse, ctrl.Result{}),
+		testComponent(recorder, appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, ctrl.Result{}),
+		testComponent(recorder, appsv1beta1.ConditionTypeFrontendReady, metav1.ConditionTrue, ctrl.Result{},
+			appsv1beta1.ConditionTypeDatabaseReady, appsv1beta1.ConditionTypeRBACReady),
+		// Blocked in turn by the frontend waiting for the database
+		testComponent(recorder, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionTrue, ctrl.Result{},
+			appsv1beta1.ConditionTypeFrontendReady),
+	}
+
+	if _, errs := (&PacmanGameReconciler{}).runComponents(context.Background(), cr, components, Defaults{}, logr.Discard()); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var reconciled []string
+	for conditionType := range recorder.waves {
+		reconciled = append(reconciled, conditionType)
+	}
+	sort.Strings(reconciled)
+	if want := []string{appsv1beta1.ConditionTypeDatabaseReady, appsv1beta1.ConditionTypeRBACReady}; !reflect.DeepEqual(reconciled, want) {
+		t.Errorf("expected only %v to be reconciled, got %v", want, reconciled)
+	}
+	for conditionType, blockedOn := range map[string]string{
+		appsv1beta1.ConditionTypeFrontendReady:   appsv1beta1.ConditionTypeDatabaseReady,
+		appsv1beta1.ConditionTypeMonitoringReady: appsv1beta1.ConditionTypeFrontendReady,
+	} {
+		condition := meta.FindStatusCondition(cr.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != appsv1beta1.ReasonWaitingForDependency || condition.Message != "Blocked on "+blockedOn {
+			t.Errorf("expected %s to wait for %s, got %+v", conditionType, blockedOn, condition)
+		}
+	}
+}
+
+func TestRunComponentsReportsFailures(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "failing-game", Namespace: "pacman"}}
+	defer deleteGameMetrics(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+	recorder := &stepRecorder{waves: map[string]int{}}
+	failing := component{
+		conditionType: appsv1beta1.ConditionTypeDatabaseReady,
+		steps: []componentStep{{name: "MongoDeployment", reconcile: func(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+			return ctrl.Result{}, errors.New("quota exceeded")
+		}}},
+	}
+	components := []component{
+		failing,
+		testComponent(recorder, appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, ctrl.Result{}),
+	}
+
+	_, errs := (&PacmanGameReconciler{}).runComponents(context.Background(), cr, components, Defaults{}, logr.Discard())
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "failed to reconcile MongoDeployment: quota exceeded") {
+		t.Errorf("expected the failure of the database, got %v", errs)
+	}
+	if condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeDatabaseReady); condition == nil || condition.Reason != appsv1beta1.ReasonReconcileFailed {
+		t.Errorf("expected the database to be reported as failed, got %+v", condition)
+	}
+	// The other components of the wave still run
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeRBACReady) {
+		t.Errorf("expected the RBAC to be reconciled, got %+v", cr.Status.Conditions)
+	}
+}
+
+func TestRunComponentsDependencyCycle(t *testing.T) {
+	recorder := &stepRecorder{waves: map[string]int{}}
+	for name, components := range map[string][]component{
+		"cycle": {
+			testComponent(recorder, appsv1beta1.ConditionTypeDatabaseReady, metav1.ConditionTrue, ctrl.Result{}, appsv1beta1.ConditionTypeFrontendReady),
+			testComponent(recorder, appsv1beta1.ConditionTypeFrontendReady, metav1.ConditionTrue, ctrl.Result{}, appsv1beta1.ConditionTypeDatabaseReady),
+		},
+		"unknown dependency": {
+			testComponent(recorder, appsv1beta1.ConditionTypeFrontendReady, metav1.ConditionTrue, ctrl.Result{}, "CacheReady"),
+		},
+	} {
+		cr := &appsv1beta1.PacmanGame{}
+		_, errs := (&PacmanGameReconciler{}).runComponents(context.Background(), cr, components, Defaults{}, logr.Discard())
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unable to resolve the dependencies") {
+			t.Errorf("%s: expected a dependency error, got %v", name, errs)
+		}
+	}
+	if len(recorder.waves) != 0 {
+		t.Errorf("expected no component to be reconciled, got %v", recorder.waves)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ctrl.Result
+		want ctrl.Result
+	}{
+		{"none", ctrl.Result{}, ctrl.Result{}, ctrl.Result{}},
+		{"requeue", ctrl.Result{}, ctrl.Result{Requeue: true}, ctrl.Result{Requeue: true}},
+		{"first requeue after", ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{}, ctrl.Result{RequeueAfter: time.Minute}},
+		{"second requeue after", ctrl.Result{}, ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{RequeueAfter: time.Minute}},
+		{"minimum requeue after", ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{RequeueAfter: time.Second}, ctrl.Result{RequeueAfter: time.Second}},
+		{"both", ctrl.Result{Requeue: true, RequeueAfter: time.Second}, ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{Requeue: true, RequeueAfter: time.Second}},
+	}
+	for _, test := range tests {
+		if got := mergeResults(test.a, test.b); got != test.want {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestMergeComponentStatus(t *testing.T) {
+	base := &appsv1beta1.PacmanGame{}
+	setCondition(base, appsv1beta1.ConditionTypeDatabaseReady, metav1.ConditionTrue, appsv1beta1.ReasonDeploymentAvailable, "ready")
+	recordDrift(base, "Service", "pacman-game", &appsv1beta1.DriftedObject{Kind: "Service", Name: "pacman-game", Missing: true})
+	recordDrift(base, "Deployment", "mongo-game", &appsv1beta1.DriftedObject{Kind: "Deployment", Name: "mongo-game", Missing: true})
+	cr := base.DeepCopy()
+	// Another component of the wave already merged its changes
+	setCondition(cr, appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, appsv1beta1.ReasonRBACConfigured, "configured")
+
+	updated := base.DeepCopy()
+	setCondition(updated, appsv1beta1.ConditionTypeExposureReady, metav1.ConditionFalse, appsv1beta1.ReasonLoadBalancerPending, "pending")
+	recordDrift(updated, "Service", "pacman-game", nil)
+	updated.Status.AppPods = []string{"pacman-game-1"}
+	mergeComponentStatus(cr, base, updated)
+
+	for _, conditionType := range []string{appsv1beta1.ConditionTypeDatabaseReady, appsv1beta1.ConditionTypeRBACReady, appsv1beta1.ConditionTypeExposureReady} {
+		if meta.FindStatusCondition(cr.Status.Conditions, conditionType) == nil {
+			t.Errorf("expected %s to be kept or merged, got %+v", conditionType, cr.Status.Conditions)
+		}
+	}
+	// The drift the component cleared is removed, the rest is kept
+	if len(cr.Status.Drift) != 1 || cr.Status.Drift[0].Kind != "Deployment" {
+		t.Errorf("expected only the mongo drift to be left, got %+v", cr.Status.Drift)
+	}
+	if !reflect.DeepEqual(cr.Status.AppPods, []string{"pacman-game-1"}) {
+		t.Errorf("expected the pods to be merged, got %v", cr.Status.AppPods)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// componentConditionTypes are the conditions aggregated into the Ready condition
+var componentConditionTypes = []string{
+	appsv1beta1.ConditionTypeDatabaseReady,
+	appsv1beta1.ConditionTypeFrontendReady,
+	appsv1beta1.ConditionTypeExposureReady,
+	appsv1beta1.ConditionTypeRBACReady,
+	appsv1beta1.ConditionTypeMonitoringReady,
+}
+
+// rolloutBlockedConditionTypes are the conditions stopping the Pacman rollout when true, the game keeps running
+// its current version so they make it not Ready and Degraded
+var rolloutBlockedConditionTypes = []string{
+	appsv1beta1.ConditionTypeInvalidAppVersion,
+	appsv1beta1.ConditionTypeImageRejected,
+}
+
+// setCondition sets a condition on the CR status for the current CR generation
+func setCondition(cr *appsv1beta1.PacmanGame, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cr.Generation,
+	})
+}
+
+// setComponentFailed marks a component condition as failed with the error that caused it
+func setComponentFailed(cr *appsv1beta1.PacmanGame, conditionType string, err error) {
+	setCondition(cr, conditionType, metav1.ConditionFalse, appsv1beta1.ReasonReconcileFailed, err.Error())
+}
+
+// setDeploymentCondition sets a component condition from the readiness of a given deployment
+func setDeploymentCondition(cr *appsv1beta1.PacmanGame, conditionType string, deployment *appsv1.Deployment) {
+	message := fmt.Sprintf("Deployment %s has %d/%d replicas ready", deployment.Name, deployment.Status.ReadyReplicas, getDesiredReplicas(deployment))
+	if isDeploymentReady(deployment) {
+		setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonDeploymentAvailable, message)
+	} else {
+		setCondition(cr, conditionType, metav1.ConditionFalse, appsv1beta1.ReasonDeploymentProgressing, message)
+	}
+}
+
+// setServiceCondition sets a component condition from the state of a given service
+func setServiceCondition(cr *appsv1beta1.PacmanGame, conditionType string, service *corev1.Service) {
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonServiceReady, fmt.Sprintf("Service %s is ready", service.Name))
+		return
+	}
+	var addresses []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		} else if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+	}
+	if len(addresses) > 0 {
+		setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonServiceReady, fmt.Sprintf("Service %s is exposed at %s", service.Name, strings.Join(addresses, ", ")))
+		return
+	}
+	// The game is still reachable through the cluster IP while the load balancer is provisioned
+	setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonLoadBalancerPending, fmt.Sprintf("Service %s is waiting for a load balancer address", service.Name))
+}
+
+// setAggregateConditions computes the Ready, Progressing and Degraded conditions from the component conditions
+// and the conditions blocking the rollout
+func setAggregateConditions(cr *appsv1beta1.PacmanGame) {
+	var notReady, failed, blocked, misconfigured []string
+	for _, conditionType := range componentConditionTypes {
+		condition := meta.FindStatusCondition(cr.Status.Conditions, conditionType)
+		if condition != nil && condition.Reason == appsv1beta1.ReasonWaitingForDependency {
+			// Show what is blocking the component
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", conditionType, condition.Message))
+		} else if condition == nil || condition.Status != metav1.ConditionTrue {
+			notReady = append(notReady, conditionType)
+		}
+		if condition != nil && condition.Reason == appsv1beta1.ReasonReconcileFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", conditionType, condition.Message))
+		}
+		if condition != nil && condition.Reason == appsv1beta1.ReasonNoMetricsSource {
+			misconfigured = append(misconfigured, fmt.Sprintf("%s: %s", conditionType, condition.Message))
+		}
+	}
+	for _, conditionType := range rolloutBlockedConditionTypes {
+		if condition := meta.FindStatusCondition(cr.Status.Conditions, conditionType); condition != nil && condition.Status == metav1.ConditionTrue {
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", conditionType, condition.Message))
+			blocked = append(blocked, fmt.Sprintf("%s: %s", conditionType, condition.Message))
+		}
+	}
+
+	if len(failed) > 0 {
+		setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionTrue, appsv1beta1.ReasonReconcileFailed, strings.Join(append(append(failed, blocked...), misconfigured...), "; "))
+	} else if len(blocked) > 0 {
+		setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionTrue, appsv1beta1.ReasonRolloutBlocked, strings.Join(append(blocked, misconfigured...), "; "))
+	} else if len(misconfigured) > 0 {
+		setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionTrue, appsv1beta1.ReasonNoMetricsSource, strings.Join(misconfigured, "; "))
+	} else {
+		setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionFalse, appsv1beta1.ReasonComponentsHealthy, "All components reconciled successfully")
+	}
+
+	if len(notReady) == 0 {
+		setCondition(cr, appsv1beta1.ConditionTypeReady, metav1.ConditionTrue, appsv1beta1.ReasonAllComponentsReady, "All components are ready")
+		if cr.Status.FirstReadyTime == nil {
+			now := metav1.Now()
+			cr.Status.FirstReadyTime = &now
+		}
+		setCondition(cr, appsv1beta1.ConditionTypeProgressing, metav1.ConditionFalse, appsv1beta1.ReasonAllComponentsReady, "All components are ready")
+	} else {
+		message := "Waiting for " + strings.Join(notReady, ", ")
+		setCondition(cr, appsv1beta1.ConditionTypeReady, metav1.ConditionFalse, appsv1beta1.ReasonComponentsNotReady, message)
+		// Failed components, blocked rollouts and components without a metrics source are reported through Degraded,
+		// the rest are still rolling out
+		if len(failed)+len(blocked)+len(misconfigured) < len(notReady) {
+			setCondition(cr, appsv1beta1.ConditionTypeProgressing, metav1.ConditionTrue, appsv1beta1.ReasonComponentsNotReady, message)
+		} else {
+			setCondition(cr, appsv1beta1.ConditionTypeProgressing, metav1.ConditionFalse, appsv1beta1.ReasonReconcileFailed, message)
+		}
+	}
+	cr.Status.ObservedGeneration = cr.Generation
+}
+
+// setInvalidSpecConditions reports that the CR is not rendered because its spec is invalid
+func setInvalidSpecConditions(cr *appsv1beta1.PacmanGame, err error) {
+	message := "Invalid spec: " + err.Error()
+	setCondition(cr, appsv1beta1.ConditionTypeReady, metav1.ConditionFalse, appsv1beta1.ReasonInvalidSpec, message)
+	setCondition(cr, appsv1beta1.ConditionTypeProgressing, metav1.ConditionFalse, appsv1beta1.ReasonInvalidSpec, message)
+	setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionTrue, appsv1beta1.ReasonInvalidSpec, message)
+	cr.Status.ObservedGeneration = cr.Generation
+}
+
+// isSpecInvalid returns true if the current CR generation has already been reported as invalid
+func isSpecInvalid(cr *appsv1beta1.PacmanGame) bool {
+	condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeDegraded)
+	return condition != nil && condition.Reason == appsv1beta1.ReasonInvalidSpec && condition.ObservedGeneration == cr.Generation
+}
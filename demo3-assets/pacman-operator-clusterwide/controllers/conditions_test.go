@@ -0,0 +1,79 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetAggregateConditionsRolloutBlocked(t *testing.T) {
+	for _, blocked := range rolloutBlockedConditionTypes {
+		cr := &appsv1beta1.PacmanGame{}
+		for _, conditionType := range componentConditionTypes {
+			setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonDeploymentAvailable, "ready")
+		}
+		for _, conditionType := range rolloutBlockedConditionTypes {
+			setCondition(cr, conditionType, metav1.ConditionFalse, appsv1beta1.ReasonImageFound, "found")
+		}
+		setAggregateConditions(cr)
+		if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeReady) {
+			t.Fatalf("expected the game to be Ready, got %+v", cr.Status.Conditions)
+		}
+
+		// The game keeps running its current version, but the requested one is never rolled out
+		setCondition(cr, blocked, metav1.ConditionTrue, appsv1beta1.ReasonImageTagNotFound, "Tag v0.0.l doesn't exist")
+		setAggregateConditions(cr)
+		ready := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeReady)
+		degraded := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeDegraded)
+		if ready.Status != metav1.ConditionFalse || !strings.Contains(ready.Message, blocked) {
+			t.Errorf("%s: expected the game not to be Ready, got %+v", blocked, ready)
+		}
+		if degraded.Status != metav1.ConditionTrue || degraded.Reason != appsv1beta1.ReasonRolloutBlocked || !strings.Contains(degraded.Message, "v0.0.l") {
+			t.Errorf("%s: expected the game to be Degraded, got %+v", blocked, degraded)
+		}
+		if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeProgressing) {
+			t.Errorf("%s: expected a blocked rollout not to be Progressing", blocked)
+		}
+	}
+}
+
+func TestSetAggregateConditionsNoMetricsSource(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{}
+	for _, conditionType := range componentConditionTypes {
+		setCondition(cr, conditionType, metav1.ConditionTrue, appsv1beta1.ReasonDeploymentAvailable, "ready")
+	}
+	setCondition(cr, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionFalse, appsv1beta1.ReasonNoMetricsSource, "No metrics source is configured")
+	setAggregateConditions(cr)
+
+	// Nothing is rolling out, the spec has to be fixed
+	ready := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeReady)
+	degraded := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeDegraded)
+	if ready.Status != metav1.ConditionFalse || !strings.Contains(ready.Message, appsv1beta1.ConditionTypeMonitoringReady) {
+		t.Errorf("expected the game not to be Ready, got %+v", ready)
+	}
+	if degraded.Status != metav1.ConditionTrue || degraded.Reason != appsv1beta1.ReasonNoMetricsSource {
+		t.Errorf("expected the game to be Degraded, got %+v", degraded)
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeProgressing) {
+		t.Errorf("expected a game without metrics source not to be Progressing")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Environment variables holding the images of the games, they are listed as relatedImages in the CSV
+// so they can be mirrored for disconnected installs
+const (
+	RelatedImagePacmanEnvVar        = "RELATED_IMAGE_PACMAN"
+	RelatedImageMongoEnvVar         = "RELATED_IMAGE_MONGO"
+	RelatedImageMongoExporterEnvVar = "RELATED_IMAGE_MONGO_EXPORTER"
+)
+
+// Defaults holds the values used to render the objects of the PacmanGames that don't set them
+type Defaults struct {
+	// PacmanImageRepository is the Pacman image repository, the game appVersion is used as tag
+	PacmanImageRepository string
+	// PacmanImage is the Pacman image of the games not setting appVersion, it may be pinned by digest.
+	// The latest tag of PacmanImageRepository is used when empty
+	PacmanImage string
+	// MongoImage is the image of the game database
+	MongoImage string
+	// MongoExporterImage is the image of the mongo metrics exporter sidecar
+	MongoExporterImage string
+	// PacmanReplicas is the number of Pacman replicas of the games not setting spec.replicas
+	PacmanReplicas int32
+	// PacmanResources are the compute resources of the Pacman container
+	PacmanResources corev1.ResourceRequirements
+	// MongoResources are the compute resources of the mongo container
+	MongoResources corev1.ResourceRequirements
+	// PacmanServiceType is the type of the Service exposing Pacman
+	PacmanServiceType corev1.ServiceType
+}
+
+// Returns the defaults built into the operator
+func NewDefaults() Defaults {
+	return Defaults{
+		PacmanImageRepository: "quay.io/ifont/pacman-nodejs-app",
+		MongoImage:            "docker.io/library/mongo:latest",
+		MongoExporterImage:    "docker.io/percona/mongodb_exporter:0.40",
+		PacmanReplicas:        1,
+		PacmanServiceType:     corev1.ServiceTypeLoadBalancer,
+	}
+}
+
+// Override returns a copy of the defaults with the values set in a given Defaults replacing them
+func (d Defaults) Override(o Defaults) Defaults {
+	// A new repository replaces the image of the previous one
+	if o.PacmanImageRepository != "" {
+		d.PacmanImageRepository = o.PacmanImageRepository
+		d.PacmanImage = ""
+	}
+	if o.PacmanImage != "" {
+		d.PacmanImage = o.PacmanImage
+	}
+	if o.MongoImage != "" {
+		d.MongoImage = o.MongoImage
+	}
+	if o.MongoExporterImage != "" {
+		d.MongoExporterImage = o.MongoExporterImage
+	}
+	if o.PacmanReplicas != 0 {
+		d.PacmanReplicas = o.PacmanReplicas
+	}
+	if o.PacmanResources.Limits != nil || o.PacmanResources.Requests != nil {
+		d.PacmanResources = *o.PacmanResources.DeepCopy()
+	}
+	if o.MongoResources.Limits != nil || o.MongoResources.Requests != nil {
+		d.MongoResources = *o.MongoResources.DeepCopy()
+	}
+	if o.PacmanServiceType != "" {
+		d.PacmanServiceType = o.PacmanServiceType
+	}
+	return d
+}
+
+// DefaultsFromRelatedImages returns the defaults set through the RELATED_IMAGE environment variables,
+// used to run the games from mirrored images in disconnected clusters
+func DefaultsFromRelatedImages(lookupEnv func(string) (string, bool)) Defaults {
+	defaults := Defaults{}
+	if image, ok := lookupEnv(RelatedImagePacmanEnvVar); ok && image != "" {
+		// The tags requested through appVersion are rewritten onto the mirrored repository
+		defaults.PacmanImageRepository = imageRepository(image)
+		defaults.PacmanImage = image
+	}
+	if image, ok := lookupEnv(RelatedImageMongoEnvVar); ok && image != "" {
+		defaults.MongoImage = image
+	}
+	if image, ok := lookupEnv(RelatedImageMongoExporterEnvVar); ok && image != "" {
+		defaults.MongoExporterImage = image
+	}
+	return defaults
+}
+
+// imageRepository returns the repository of a given image reference, without its tag nor digest
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// A colon after the last slash separates the tag, before it's the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// pacmanImage returns the Pacman image of a given CR, the image set in the spec takes precedence over the defaults
+func (d Defaults) pacmanImage(cr *appsv1beta1.PacmanGame) string {
+	image := cr.Spec.Pacman.Image
+	repository := d.PacmanImageRepository
+	if image.Repository != "" {
+		repository = image.Repository
+	}
+	switch {
+	case image.Digest != "":
+		return repository + "@" + image.Digest
+	case image.Tag != "":
+		return repository + ":" + image.Tag
+	case image.Repository == "" && cr.Spec.AppVersion == "" && d.PacmanImage != "":
+		return d.PacmanImage
+	}
+	return repository + ":" + getAppVersion(cr)
+}
+
+// defaultsFromOperatorConfig returns the defaults set in a PacmanOperatorConfig
+func defaultsFromOperatorConfig(spec appsv1beta1.PacmanOperatorConfigSpec) Defaults {
+	return Defaults{
+		PacmanImageRepository: spec.PacmanImageRepository,
+		MongoImage:            spec.MongoImage,
+		MongoExporterImage:    spec.MongoExporterImage,
+		PacmanReplicas:        spec.PacmanReplicas,
+		PacmanResources:       spec.PacmanResources,
+		MongoResources:        spec.MongoResources,
+		PacmanServiceType:     spec.PacmanServiceType,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultsFromRelatedImages(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Defaults
+	}{
+		{name: "missing env vars", env: map[string]string{}, want: Defaults{}},
+		{name: "empty env vars", env: map[string]string{RelatedImagePacmanEnvVar: "", RelatedImageMongoEnvVar: ""}, want: Defaults{}},
+		{
+			name: "tagged images",
+			env: map[string]string{
+				RelatedImagePacmanEnvVar:        "mirror.lab/pacman/pacman-nodejs-app:v0.0.2",
+				RelatedImageMongoEnvVar:         "mirror.lab/library/mongo:5.0",
+				RelatedImageMongoExporterEnvVar: "mirror.lab/percona/mongodb_exporter:0.40",
+			},
+			want: Defaults{
+				PacmanImageRepository: "mirror.lab/pacman/pacman-nodejs-app",
+				PacmanImage:           "mirror.lab/pacman/pacman-nodejs-app:v0.0.2",
+				MongoImage:            "mirror.lab/library/mongo:5.0",
+				MongoExporterImage:    "mirror.lab/percona/mongodb_exporter:0.40",
+			},
+		},
+		{
+			name: "digest reference",
+			env:  map[string]string{RelatedImagePacmanEnvVar: "mirror.lab/pacman/pacman-nodejs-app@sha256:0123"},
+			want: Defaults{
+				PacmanImageRepository: "mirror.lab/pacman/pacman-nodejs-app",
+				PacmanImage:           "mirror.lab/pacman/pacman-nodejs-app@sha256:0123",
+			},
+		},
+		{
+			name: "registry with port",
+			env:  map[string]string{RelatedImagePacmanEnvVar: "mirror.lab:5000/pacman:v0.0.2"},
+			want: Defaults{
+				PacmanImageRepository: "mirror.lab:5000/pacman",
+				PacmanImage:           "mirror.lab:5000/pacman:v0.0.2",
+			},
+		},
+	}
+	for _, test := range tests {
+		lookupEnv := func(key string) (string, bool) {
+			value, ok := test.env[key]
+			return value, ok
+		}
+		if got := DefaultsFromRelatedImages(lookupEnv); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"pacman", "pacman"},
+		{"quay.io/ifont/pacman-nodejs-app", "quay.io/ifont/pacman-nodejs-app"},
+		{"quay.io/ifont/pacman-nodejs-app:latest", "quay.io/ifont/pacman-nodejs-app"},
+		{"quay.io/ifont/pacman-nodejs-app@sha256:0123", "quay.io/ifont/pacman-nodejs-app"},
+		{"quay.io/ifont/pacman-nodejs-app:v0.0.2@sha256:0123", "quay.io/ifont/pacman-nodejs-app"},
+		{"host:5000/repo", "host:5000/repo"},
+		{"host:5000/repo:tag", "host:5000/repo"},
+		{"host:5000/repo@sha256:0123", "host:5000/repo"},
+	}
+	for _, test := range tests {
+		if got := imageRepository(test.image); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.image, test.want, got)
+		}
+	}
+}
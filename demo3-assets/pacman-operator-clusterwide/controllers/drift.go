@@ -0,0 +1,136 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxDriftFields is the maximum number of drifted fields reported per object
+const maxDriftFields = 10
+
+// computeDrift returns how the live object differs from the object the operator would apply, nil if it doesn't
+func computeDrift(live client.Object, desired client.Object, exists bool) (*appsv1beta1.DriftedObject, error) {
+	drift := &appsv1beta1.DriftedObject{
+		Kind: desired.GetObjectKind().GroupVersionKind().Kind,
+		Name: desired.GetName(),
+	}
+	if !exists {
+		drift.Missing = true
+		return drift, nil
+	}
+
+	liveMap, err := toUnstructuredMap(live)
+	if err != nil {
+		return nil, err
+	}
+	desiredMap, err := toUnstructuredMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	// Ignore the fields that are not part of the desired state
+	for _, obj := range []map[string]interface{}{liveMap, desiredMap} {
+		delete(obj, "status")
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			for _, field := range []string{"managedFields", "resourceVersion", "generation"} {
+				delete(metadata, field)
+			}
+		}
+	}
+
+	fields := diffPaths("", liveMap, desiredMap)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sort.Strings(fields)
+	if len(fields) > maxDriftFields {
+		fields = append(fields[:maxDriftFields], fmt.Sprintf("and %d more", len(fields)-maxDriftFields))
+	}
+	drift.Fields = fields
+	return drift, nil
+}
+
+// toUnstructuredMap returns a copy of the content of a given object as an unstructured map
+func toUnstructuredMap(obj client.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(runtime.Unstructured); ok {
+		return runtime.DeepCopyJSON(u.UnstructuredContent()), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// diffPaths returns the paths of the fields that differ between two unstructured values
+func diffPaths(path string, live interface{}, desired interface{}) []string {
+	liveMap, liveIsMap := live.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if liveIsMap && desiredIsMap {
+		var paths []string
+		keys := map[string]bool{}
+		for k := range liveMap {
+			keys[k] = true
+		}
+		for k := range desiredMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			subPath := k
+			if path != "" {
+				subPath = path + "." + k
+			}
+			paths = append(paths, diffPaths(subPath, liveMap[k], desiredMap[k])...)
+		}
+		return paths
+	}
+
+	liveList, liveIsList := live.([]interface{})
+	desiredList, desiredIsList := desired.([]interface{})
+	if liveIsList && desiredIsList && len(liveList) == len(desiredList) {
+		var paths []string
+		for i := range liveList {
+			paths = append(paths, diffPaths(fmt.Sprintf("%s[%d]", path, i), liveList[i], desiredList[i])...)
+		}
+		return paths
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		return []string{path}
+	}
+	return nil
+}
+
+// recordDrift sets the drift of a given object in the CR status, removing it if the object didn't drift
+func recordDrift(cr *appsv1beta1.PacmanGame, kind string, name string, drift *appsv1beta1.DriftedObject) {
+	drifted := make([]appsv1beta1.DriftedObject, 0, len(cr.Status.Drift))
+	for _, d := range cr.Status.Drift {
+		if d.Kind == kind && d.Name == name {
+			continue
+		}
+		drifted = append(drifted, d)
+	}
+	if drift != nil {
+		drifted = append(drifted, *drift)
+	}
+	if len(drifted) == 0 {
+		drifted = nil
+	}
+	cr.Status.Drift = drifted
+}
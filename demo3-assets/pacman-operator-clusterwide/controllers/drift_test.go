@@ -0,0 +1,163 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// configMapWithData returns a ConfigMap holding given data
+func configMapWithData(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "pacman-game-dashboard", Namespace: "pacman", ResourceVersion: "1"},
+		Data:       data,
+	}
+}
+
+func TestComputeDrift(t *testing.T) {
+	// The live object is annotated by another manager, the dry run apply keeps the fields it doesn't own
+	annotated := configMapWithData(map[string]string{"dashboard.json": "{}"})
+	annotated.Annotations = map[string]string{"owner": "someone-else"}
+	desiredAnnotated := annotated.DeepCopy()
+	// The dry run is answered with the next resource version and the operator managed fields
+	desiredAnnotated.ResourceVersion = "2"
+	desiredAnnotated.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: FieldManager, Operation: metav1.ManagedFieldsOperationApply}}
+
+	tests := []struct {
+		name    string
+		live    *corev1.ConfigMap
+		desired *corev1.ConfigMap
+		exists  bool
+		want    *appsv1beta1.DriftedObject
+	}{
+		{
+			name:    "unchanged",
+			live:    configMapWithData(map[string]string{"dashboard.json": "{}"}),
+			desired: configMapWithData(map[string]string{"dashboard.json": "{}"}),
+			exists:  true,
+		},
+		{
+			name:    "changed field",
+			live:    configMapWithData(map[string]string{"dashboard.json": "edited"}),
+			desired: configMapWithData(map[string]string{"dashboard.json": "{}"}),
+			exists:  true,
+			want:    &appsv1beta1.DriftedObject{Kind: "ConfigMap", Name: "pacman-game-dashboard", Fields: []string{"data.dashboard.json"}},
+		},
+		{
+			name:    "removed field",
+			live:    configMapWithData(map[string]string{"README": "Pacman dashboard"}),
+			desired: configMapWithData(map[string]string{"README": "Pacman dashboard", "dashboard.json": "{}"}),
+			exists:  true,
+			want:    &appsv1beta1.DriftedObject{Kind: "ConfigMap", Name: "pacman-game-dashboard", Fields: []string{"data.dashboard.json"}},
+		},
+		{
+			name:    "field not owned by the operator",
+			live:    annotated,
+			desired: desiredAnnotated,
+			exists:  true,
+		},
+		{
+			name:    "missing object",
+			live:    &corev1.ConfigMap{},
+			desired: configMapWithData(map[string]string{"dashboard.json": "{}"}),
+			want:    &appsv1beta1.DriftedObject{Kind: "ConfigMap", Name: "pacman-game-dashboard", Missing: true},
+		},
+	}
+	for _, test := range tests {
+		drift, err := computeDrift(test.live, test.desired, test.exists)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(drift, test.want) {
+			t.Errorf("%s: expected drift %+v, got %+v", test.name, test.want, drift)
+		}
+	}
+}
+
+func TestComputeDriftLimitsFields(t *testing.T) {
+	live := configMapWithData(map[string]string{"a": "edited"})
+	desired := configMapWithData(map[string]string{})
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		desired.Data[key] = key
+	}
+	drift, err := computeDrift(live, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift.Fields) != maxDriftFields+1 || drift.Fields[maxDriftFields] != "and 2 more" {
+		t.Errorf("expected %d fields and a summary, got %v", maxDriftFields, drift.Fields)
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{map[string]interface{}{"port": int64(8080)}},
+			"type":  "ClusterIP",
+		},
+	}
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{map[string]interface{}{"port": int64(80)}},
+			"type":  "ClusterIP",
+		},
+	}
+	if paths := diffPaths("", live, desired); !reflect.DeepEqual(paths, []string{"spec.ports[0].port"}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+	// Lists of different length are reported as a whole
+	desired["spec"].(map[string]interface{})["ports"] = []interface{}{}
+	if paths := diffPaths("", live, desired); !reflect.DeepEqual(paths, []string{"spec.ports"}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
+
+func TestRecordDrift(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{}
+	deploymentDrift := &appsv1beta1.DriftedObject{Kind: "Deployment", Name: "pacman-game", Fields: []string{"spec.replicas"}}
+	serviceDrift := &appsv1beta1.DriftedObject{Kind: "Service", Name: "pacman-game", Missing: true}
+
+	// Added
+	recordDrift(cr, "Deployment", "pacman-game", deploymentDrift)
+	recordDrift(cr, "Service", "pacman-game", serviceDrift)
+	if want := []appsv1beta1.DriftedObject{*deploymentDrift, *serviceDrift}; !reflect.DeepEqual(cr.Status.Drift, want) {
+		t.Errorf("expected drift %+v, got %+v", want, cr.Status.Drift)
+	}
+
+	// Updated, the previous drift of the object is replaced
+	updated := &appsv1beta1.DriftedObject{Kind: "Deployment", Name: "pacman-game", Fields: []string{"spec.template.spec.containers[0].image"}}
+	recordDrift(cr, "Deployment", "pacman-game", updated)
+	if want := []appsv1beta1.DriftedObject{*serviceDrift, *updated}; !reflect.DeepEqual(cr.Status.Drift, want) {
+		t.Errorf("expected drift %+v, got %+v", want, cr.Status.Drift)
+	}
+
+	// Removed, the drift is cleared once no object drifts
+	recordDrift(cr, "Deployment", "pacman-game", nil)
+	if want := []appsv1beta1.DriftedObject{*serviceDrift}; !reflect.DeepEqual(cr.Status.Drift, want) {
+		t.Errorf("expected drift %+v, got %+v", want, cr.Status.Drift)
+	}
+	recordDrift(cr, "Service", "pacman-game", nil)
+	if cr.Status.Drift != nil {
+		t.Errorf("expected no drift, got %+v", cr.Status.Drift)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/mvazquezc/pacman-operator/internal/cosign"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// missingImageRetryInterval is how often a missing Pacman image tag is looked up again, it may be pushed later
+const missingImageRetryInterval = 5 * time.Minute
+
+const (
+	// verifiedImageTTL is how long a verified Pacman image is trusted before its signatures are checked again,
+	// so a signature removed from the registry stops being trusted
+	verifiedImageTTL = 10 * time.Minute
+	// maxVerifiedImages bounds the number of verified Pacman images remembered, the least recently used are evicted
+	maxVerifiedImages = 1024
+)
+
+// resolvePacmanImage returns the image the Pacman containers of a given CR run and records it in status.appImage.
+// The requested tag is looked up in the registry so a missing tag is never rolled out, the image is pinned by
+// digest when ResolveImageDigests is set. The digest resolved for a reference is kept until the reference
+// changes, or until spec.pacman.image.refreshInterval elapses when set.
+// When the requested tag doesn't exist the InvalidAppVersion condition is set and status.appImage is left untouched
+func (r *PacmanGameReconciler) resolvePacmanImage(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (string, ctrl.Result, error) {
+	reference := defaults.pacmanImage(cr)
+	ref, err := name.ParseReference(reference)
+	if err != nil {
+		return "", ctrl.Result{}, err
+	}
+	// References pinned by digest don't need the registry
+	if digest, ok := ref.(name.Digest); ok || r.Registry == nil {
+		setCondition(cr, appsv1beta1.ConditionTypeInvalidAppVersion, metav1.ConditionFalse, appsv1beta1.ReasonImageNotChecked, "Image "+reference+" is deployed without checking the registry")
+		cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: reference, Digest: digest.DigestStr(), Image: reference}
+		return reference, ctrl.Result{}, nil
+	}
+	// The games are deployed by tag unless the digests are pinned, the tag is only checked to exist
+	imageOf := func(digest string) string {
+		if r.ResolveImageDigests {
+			return imageRepository(reference) + "@" + digest
+		}
+		return reference
+	}
+
+	refreshInterval := time.Duration(0)
+	if cr.Spec.Pacman.Image.RefreshInterval != nil {
+		refreshInterval = cr.Spec.Pacman.Image.RefreshInterval.Duration
+	}
+	previous := cr.Status.AppImage
+	resolved := previous != nil && previous.Reference == reference && previous.Digest != "" && previous.ResolvedTime != nil
+	if resolved {
+		setCondition(cr, appsv1beta1.ConditionTypeInvalidAppVersion, metav1.ConditionFalse, appsv1beta1.ReasonImageFound, "Image "+reference+" resolved to "+previous.Digest)
+		previous.Image = imageOf(previous.Digest)
+		if refreshInterval <= 0 {
+			return previous.Image, ctrl.Result{}, nil
+		}
+		if remaining := time.Until(previous.ResolvedTime.Add(refreshInterval)); remaining > 0 {
+			return previous.Image, ctrl.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "PacmanGame.ResolveImage", attribute.String("image.reference", reference))
+	defer span.End()
+	keychain, err := r.pullSecretsKeychain(ctx, cr)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return "", ctrl.Result{}, err
+	}
+	digest, err := r.Registry.Digest(ctx, reference, keychain)
+	if err != nil {
+		tracing.RecordError(span, err)
+		if resolved {
+			// Keep running the resolved digest, the tag is resolved again on the next refresh
+			log.Error(err, "Failed to refresh the Pacman image digest, keeping the resolved digest", "Image", reference, "Digest", previous.Digest)
+			return previous.Image, ctrl.Result{RequeueAfter: refreshInterval}, nil
+		}
+		if errors.Is(err, registry.ErrManifestUnknown) {
+			// Rolling out a missing tag would take the game down with ImagePullBackOff
+			message := fmt.Sprintf("Tag %s doesn't exist in %s, the Pacman Deployment keeps running its current version", ref.Identifier(), ref.Context().Name())
+			if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion) {
+				r.recordEvent(cr, corev1.EventTypeWarning, EventReasonInvalidAppVersion, message)
+			}
+			log.Info("Pacman image tag not found", "Image", reference)
+			setCondition(cr, appsv1beta1.ConditionTypeInvalidAppVersion, metav1.ConditionTrue, appsv1beta1.ReasonImageTagNotFound, message)
+			return "", ctrl.Result{RequeueAfter: missingImageRetryInterval}, nil
+		}
+		if r.ResolveImageDigests {
+			return "", ctrl.Result{}, err
+		}
+		// Games deployed by tag don't need the registry, the nodes may pull through a mirror the operator can't reach
+		log.Error(err, "Failed to check the Pacman image in the registry, deploying it by tag", "Image", reference)
+		setCondition(cr, appsv1beta1.ConditionTypeInvalidAppVersion, metav1.ConditionFalse, appsv1beta1.ReasonImageNotChecked, "Image "+reference+" is deployed without checking the registry: "+err.Error())
+		cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: reference, Image: reference}
+		return reference, ctrl.Result{RequeueAfter: missingImageRetryInterval}, nil
+	}
+	span.SetAttributes(attribute.String("image.digest", digest))
+	setCondition(cr, appsv1beta1.ConditionTypeInvalidAppVersion, metav1.ConditionFalse, appsv1beta1.ReasonImageFound, "Image "+reference+" resolved to "+digest)
+
+	image := imageOf(digest)
+	if !resolved || previous.Digest != digest {
+		log.Info("Resolved Pacman image digest", "Image", reference, "Digest", digest)
+	}
+	now := metav1.Now()
+	cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: reference, Digest: digest, Image: image, ResolvedTime: &now}
+	if refreshInterval > 0 {
+		return image, ctrl.Result{RequeueAfter: refreshInterval}, nil
+	}
+	return image, ctrl.Result{}, nil
+}
+
+// verifyPacmanImage checks a given Pacman image against the image verification policy and sets the ImageRejected
+// condition. Verified images are remembered by digest for verifiedImageTTL, the policy is only read at startup
+// so it can't change while they are remembered. status.appImage.signedBy is cleared unless the image is verified
+func (r *PacmanGameReconciler) verifyPacmanImage(ctx context.Context, cr *appsv1beta1.PacmanGame, image string, log logr.Logger) (ctrl.Result, error) {
+	if cr.Status.AppImage != nil {
+		cr.Status.AppImage.SignedBy = ""
+	}
+	if r.ImagePolicy == nil || !r.ImagePolicy.Requires(image) {
+		setCondition(cr, appsv1beta1.ConditionTypeImageRejected, metav1.ConditionFalse, appsv1beta1.ReasonSignatureNotRequired, "Image "+image+" doesn't require a signature")
+		return ctrl.Result{}, nil
+	}
+	var key string
+	// Images not pinned by digest are never verified, so they are never remembered
+	digest := ""
+	if ref, err := name.ParseReference(image); err == nil {
+		if digestRef, ok := ref.(name.Digest); ok {
+			digest = digestRef.DigestStr()
+		}
+	}
+	if verified, ok := r.verifiedImages.Get(digest); ok && digest != "" {
+		key = verified.(string)
+	} else {
+		ctx, span := tracing.StartSpan(ctx, "PacmanGame.VerifyImage", attribute.String("image.reference", image))
+		defer span.End()
+		keychain, err := r.pullSecretsKeychain(ctx, cr)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return ctrl.Result{}, err
+		}
+		key, err = r.ImagePolicy.Verify(ctx, image, keychain)
+		if errors.Is(err, cosign.ErrVerificationFailed) {
+			tracing.RecordError(span, err)
+			// The image may be signed later, the signature is looked up again
+			message := err.Error() + ", the Pacman Deployment keeps running its current version"
+			if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) {
+				r.recordEvent(cr, corev1.EventTypeWarning, EventReasonImageRejected, message)
+			}
+			log.Info("Pacman image rejected", "Image", image, "Reason", err.Error())
+			setCondition(cr, appsv1beta1.ConditionTypeImageRejected, metav1.ConditionTrue, appsv1beta1.ReasonSignatureVerificationFailed, message)
+			return ctrl.Result{RequeueAfter: missingImageRetryInterval}, nil
+		}
+		if err != nil {
+			tracing.RecordError(span, err)
+			return ctrl.Result{}, err
+		}
+		log.Info("Verified Pacman image signature", "Image", image, "Key", key)
+		r.verifiedImages.Add(digest, key, verifiedImageTTL)
+	}
+	setCondition(cr, appsv1beta1.ConditionTypeImageRejected, metav1.ConditionFalse, appsv1beta1.ReasonSignatureVerified, fmt.Sprintf("Image %s is signed with key %s", image, key))
+	if cr.Status.AppImage != nil {
+		cr.Status.AppImage.SignedBy = key
+	}
+	return ctrl.Result{}, nil
+}
+
+// pullSecretsKeychain returns the registry credentials held in the image pull secrets of a given CR, the first
+// secret holding credentials for a registry is used. Missing secrets are skipped, the registry may not need them
+func (r *PacmanGameReconciler) pullSecretsKeychain(ctx context.Context, cr *appsv1beta1.PacmanGame) (authn.Keychain, error) {
+	var keychains []authn.Keychain
+	for _, pullSecret := range cr.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: pullSecret.Name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		var keychain authn.Keychain
+		var err error
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			keychain, err = registry.NewDockerConfigKeychain(secret.Data[corev1.DockerConfigJsonKey], false)
+		case corev1.SecretTypeDockercfg:
+			keychain, err = registry.NewDockerConfigKeychain(secret.Data[corev1.DockerConfigKey], true)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid image pull secret %s: %w", secret.Name, err)
+		}
+		keychains = append(keychains, keychain)
+	}
+	return authn.NewMultiKeychain(keychains...), nil
+}
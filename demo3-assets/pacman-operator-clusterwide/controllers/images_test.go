@@ -0,0 +1,375 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/authn"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/mvazquezc/pacman-operator/internal/cosign"
+	"github.com/mvazquezc/pacman-operator/internal/cosign/cosigntest"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/registry/registrytest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newImageTestGame(reg *registrytest.Registry) *appsv1beta1.PacmanGame {
+	return &appsv1beta1.PacmanGame{
+		ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"},
+		Spec: appsv1beta1.PacmanGameSpec{
+			Pacman: appsv1beta1.PacmanGamePacman{
+				Image: appsv1beta1.PacmanImage{Repository: reg.Host() + "/ifont/pacman-nodejs-app", Tag: "latest"},
+			},
+		},
+	}
+}
+
+func TestResolvePacmanImagePinsDigest(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	first := reg.Push("ifont/pacman-nodejs-app", "latest")
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), Registry: registry.NewClient(nil, 0), ResolveImageDigests: true}
+	cr := newImageTestGame(reg)
+
+	image, result, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := reg.Host() + "/ifont/pacman-nodejs-app@" + first; image != expected {
+		t.Errorf("expected %s, got %s", expected, image)
+	}
+	if cr.Status.AppImage == nil || cr.Status.AppImage.Digest != first || cr.Status.AppImage.Reference != reg.Host()+"/ifont/pacman-nodejs-app:latest" {
+		t.Errorf("unexpected status %+v", cr.Status.AppImage)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue without a refresh interval, got %s", result.RequeueAfter)
+	}
+
+	// The tag moves, the game keeps running the pinned digest without querying the registry
+	reg.Push("ifont/pacman-nodejs-app", "latest")
+	requests := reg.Requests()
+	if image, _, err = r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(image, first) || reg.Requests() != requests {
+		t.Errorf("expected the pinned digest %s without requests, got %s", first, image)
+	}
+}
+
+func TestResolvePacmanImageRefreshesFloatingTag(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	reg.Push("ifont/pacman-nodejs-app", "latest")
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), Registry: registry.NewClient(nil, 0), ResolveImageDigests: true}
+	cr := newImageTestGame(reg)
+	cr.Spec.Pacman.Image.RefreshInterval = &metav1.Duration{Duration: time.Hour}
+
+	_, result, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter != time.Hour {
+		t.Errorf("expected a requeue after the refresh interval, got %s", result.RequeueAfter)
+	}
+
+	// Once the interval elapsed the moved tag is resolved again
+	moved := reg.Push("ifont/pacman-nodejs-app", "latest")
+	resolved := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	cr.Status.AppImage.ResolvedTime = &resolved
+	image, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(image, "@"+moved) || cr.Status.AppImage.Digest != moved {
+		t.Errorf("expected the moved digest %s, got %s", moved, image)
+	}
+}
+
+func TestResolvePacmanImageWithPullSecret(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	reg.RequireAuth("pacman", "s3cr3t")
+	expected := reg.Push("ifont/pacman-nodejs-app", "latest")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "pacman"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: reg.DockerConfigJSON("pacman", "s3cr3t")},
+	}
+	r := &PacmanGameReconciler{
+		Client:              fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(secret).Build(),
+		Registry:            registry.NewClient(nil, 0),
+		ResolveImageDigests: true,
+	}
+	cr := newImageTestGame(reg)
+	cr.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "missing"}, {Name: "pull-secret"}}
+
+	image, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(image, "@"+expected) {
+		t.Errorf("expected the digest %s, got %s", expected, image)
+	}
+}
+
+func TestResolvePacmanImageMissingTag(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	running := reg.Push("ifont/pacman-nodejs-app", "v0.0.1")
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), Registry: registry.NewClient(nil, 0), ResolveImageDigests: true}
+	cr := newImageTestGame(reg)
+	cr.Spec.Pacman.Image.Tag = "v0.0.1"
+	if _, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A typo in the tag leaves the running version untouched
+	cr.Spec.Pacman.Image.Tag = "v0.0.l"
+	image, result, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion)
+	if condition == nil || condition.Status != metav1.ConditionTrue || !strings.Contains(condition.Message, "v0.0.l") {
+		t.Errorf("expected the InvalidAppVersion condition naming the tag, got %+v", condition)
+	}
+	if image != "" || cr.Status.AppImage.Digest != running || result.RequeueAfter != missingImageRetryInterval {
+		t.Errorf("expected the running image %s to be kept, got %q, status %+v and %+v", running, image, cr.Status.AppImage, result)
+	}
+
+	// The tag is fixed
+	cr.Spec.Pacman.Image.Tag = "v0.0.1"
+	if _, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion) {
+		t.Error("expected the InvalidAppVersion condition to be cleared")
+	}
+}
+
+// resolverFunc adapts a function to the registry.Resolver interface
+type resolverFunc func(ctx context.Context, image string, keychain authn.Keychain) (string, error)
+
+func (f resolverFunc) Digest(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+	return f(ctx, image, keychain)
+}
+
+func TestResolvePacmanImageRegistryUnavailable(t *testing.T) {
+	unavailable := errors.New("connection refused")
+	r := &PacmanGameReconciler{
+		Client: fake.NewClientBuilder().Build(),
+		Registry: resolverFunc(func(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+			return "", unavailable
+		}),
+		ResolveImageDigests: true,
+	}
+	cr := &appsv1beta1.PacmanGame{Spec: appsv1beta1.PacmanGameSpec{AppVersion: "v0.0.1"}}
+
+	// Only missing tags are reported as invalid, other failures are retried
+	if _, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard()); !errors.Is(err, unavailable) {
+		t.Errorf("expected the registry error, got %v", err)
+	}
+	if meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion) != nil {
+		t.Error("expected no InvalidAppVersion condition")
+	}
+
+	// Games deployed by tag don't wait for the registry
+	r.ResolveImageDigests = false
+	defaults := NewDefaults()
+	image, result, err := r.resolvePacmanImage(context.Background(), cr, defaults, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion)
+	if image != defaults.pacmanImage(cr) || condition == nil || condition.Reason != appsv1beta1.ReasonImageNotChecked || result.RequeueAfter == 0 {
+		t.Errorf("expected the game deployed by tag and checked again later, got %s, %+v and %+v", image, condition, result)
+	}
+}
+
+func TestResolvePacmanImageChecksTagWithoutPinning(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	digest := reg.Push("ifont/pacman-nodejs-app", "v0.0.1")
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), Registry: registry.NewClient(nil, 0)}
+	cr := newImageTestGame(reg)
+	cr.Spec.Pacman.Image.Tag = "v0.0.1"
+	reference := NewDefaults().pacmanImage(cr)
+
+	image, _, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != reference || cr.Status.AppImage.Image != reference || cr.Status.AppImage.Digest != digest {
+		t.Errorf("expected the game deployed by tag %s, got %s and status %+v", reference, image, cr.Status.AppImage)
+	}
+
+	// A missing tag isn't rolled out even though the games are deployed by tag
+	cr.Spec.Pacman.Image.Tag = "v0.0.l"
+	image, result, err := r.resolvePacmanImage(context.Background(), cr, NewDefaults(), logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "" || !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion) || result.RequeueAfter != missingImageRetryInterval {
+		t.Errorf("expected the missing tag to be reported, got %q, %+v and %+v", image, cr.Status.Conditions, result)
+	}
+}
+
+func TestResolvePacmanImageWithoutRegistry(t *testing.T) {
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build()}
+	cr := &appsv1beta1.PacmanGame{Spec: appsv1beta1.PacmanGameSpec{AppVersion: "v0.0.1"}}
+	defaults := NewDefaults()
+
+	image, _, err := r.resolvePacmanImage(context.Background(), cr, defaults, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != defaults.pacmanImage(cr) || cr.Status.AppImage.Digest != "" {
+		t.Errorf("expected the game deployed by tag, got %s and status %+v", image, cr.Status.AppImage)
+	}
+}
+
+func TestVerifyPacmanImage(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	key, publicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := cosign.ParsePublicKey("release", publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := cosign.NewPolicy([]string{reg.Host() + "/ifont/*"}, []cosign.PublicKey{trusted}, registry.NewClient(nil, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), ImagePolicy: policy, verifiedImages: utilcache.NewLRUExpireCache(maxVerifiedImages)}
+	signed := reg.Push("ifont/pacman-nodejs-app", "v1")
+	if err := cosigntest.Sign(reg, "ifont/pacman-nodejs-app", signed, key); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := reg.Push("ifont/pacman-nodejs-app", "v2")
+
+	cr := &appsv1beta1.PacmanGame{Status: appsv1beta1.PacmanGameStatus{AppImage: &appsv1beta1.AppImageStatus{}}}
+	image := reg.Host() + "/ifont/pacman-nodejs-app@" + signed
+	if _, err := r.verifyPacmanImage(context.Background(), cr, image, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) || cr.Status.AppImage.SignedBy != "release" {
+		t.Errorf("expected the signed image to be accepted, got %+v and %+v", cr.Status.Conditions, cr.Status.AppImage)
+	}
+	// The verified image isn't checked again
+	requests := reg.Requests()
+	if _, err := r.verifyPacmanImage(context.Background(), cr, image, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if reg.Requests() != requests {
+		t.Errorf("expected no registry request, got %d", reg.Requests()-requests)
+	}
+
+	for name, image := range map[string]string{
+		"unsigned":      reg.Host() + "/ifont/pacman-nodejs-app@" + unsigned,
+		"not by digest": reg.Host() + "/ifont/pacman-nodejs-app:v1",
+	} {
+		// The game was running the signed image, its signer is cleared once it's rejected
+		cr := &appsv1beta1.PacmanGame{Status: appsv1beta1.PacmanGameStatus{AppImage: &appsv1beta1.AppImageStatus{SignedBy: "release"}}}
+		result, err := r.verifyPacmanImage(context.Background(), cr, image, logr.Discard())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) || result.RequeueAfter == 0 {
+			t.Errorf("%s: expected the image to be rejected, got %+v", name, cr.Status.Conditions)
+		}
+		if cr.Status.AppImage.SignedBy != "" {
+			t.Errorf("%s: expected the signer to be cleared, got %s", name, cr.Status.AppImage.SignedBy)
+		}
+	}
+
+	cr = &appsv1beta1.PacmanGame{Status: appsv1beta1.PacmanGameStatus{AppImage: &appsv1beta1.AppImageStatus{SignedBy: "release"}}}
+	if _, err := r.verifyPacmanImage(context.Background(), cr, "quay.io/other/pacman:v1", logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected); condition == nil || condition.Reason != appsv1beta1.ReasonSignatureNotRequired {
+		t.Errorf("expected no signature to be required, got %+v", condition)
+	}
+	if cr.Status.AppImage.SignedBy != "" {
+		t.Errorf("expected the signer of an image not requiring a signature to be cleared, got %s", cr.Status.AppImage.SignedBy)
+	}
+}
+
+func TestVerifyPacmanImageAfterKeyRevoked(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	key, publicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPublicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := cosign.ParsePublicKey("release", publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := cosign.ParsePublicKey("other", otherPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repositories := []string{reg.Host() + "/ifont/*"}
+	policy, err := cosign.NewPolicy(repositories, []cosign.PublicKey{release, other}, registry.NewClient(nil, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), ImagePolicy: policy, verifiedImages: utilcache.NewLRUExpireCache(maxVerifiedImages)}
+	signed := reg.Push("ifont/pacman-nodejs-app", "v1")
+	if err := cosigntest.Sign(reg, "ifont/pacman-nodejs-app", signed, key); err != nil {
+		t.Fatal(err)
+	}
+	image := reg.Host() + "/ifont/pacman-nodejs-app@" + signed
+	cr := &appsv1beta1.PacmanGame{}
+	if _, err := r.verifyPacmanImage(context.Background(), cr, image, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) {
+		t.Fatalf("expected the signed image to be accepted, got %+v", cr.Status.Conditions)
+	}
+
+	// The release key is removed from the configuration, the policy is read at startup so the operator is
+	// restarted with an empty cache and the image verified with the revoked key isn't trusted anymore
+	if policy, err = cosign.NewPolicy(repositories, []cosign.PublicKey{other}, registry.NewClient(nil, 0)); err != nil {
+		t.Fatal(err)
+	}
+	r = &PacmanGameReconciler{Client: fake.NewClientBuilder().Build(), ImagePolicy: policy, verifiedImages: utilcache.NewLRUExpireCache(maxVerifiedImages)}
+	if _, err := r.verifyPacmanImage(context.Background(), cr, image, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) {
+		t.Errorf("expected the image signed with the revoked key to be rejected, got %+v", cr.Status.Conditions)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"sync"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Labels of the PacmanGame metrics
+var gameLabels = []string{"namespace", "name", "app_version"}
+
+var (
+	gameReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pacman_operator_game_ready",
+		Help: "Whether the PacmanGame is ready (1) or not (0)",
+	}, gameLabels)
+	gameDesiredReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pacman_operator_game_desired_replicas",
+		Help: "Number of Pacman replicas requested by the PacmanGame",
+	}, gameLabels)
+	gameReadyReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pacman_operator_game_ready_replicas",
+		Help: "Number of Pacman replicas ready for the PacmanGame",
+	}, gameLabels)
+	gameTimeToFirstReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pacman_operator_game_time_to_first_ready_seconds",
+		Help: "Time elapsed from the PacmanGame creation until it was ready for the first time",
+	}, gameLabels)
+	gameReconcileFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pacman_operator_game_reconcile_failures_total",
+		Help: "Number of failed reconciles of the PacmanGame by reconcile step",
+	}, append(gameLabels, "step"))
+	gameUpgrades = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pacman_operator_game_upgrades_total",
+		Help: "Number of Pacman image upgrades performed on the PacmanGame",
+	}, gameLabels)
+)
+
+// gameSeries holds the label values the metrics of a PacmanGame were reported with
+type gameSeries struct {
+	// version is the app version the gauges were last reported with, the series of the previous version are
+	// removed when the game is upgraded
+	version string
+	// failures holds the app version and step of the reconcile failures counted
+	failures map[[2]string]bool
+	// upgrades holds the app versions of the upgrades counted
+	upgrades map[string]bool
+}
+
+// gameMetricsSeries holds the series reported for every PacmanGame, so they can be removed when it's deleted
+var gameMetricsSeries = struct {
+	sync.Mutex
+	games map[types.NamespacedName]*gameSeries
+}{games: map[types.NamespacedName]*gameSeries{}}
+
+func init() {
+	// Register the PacmanGame metrics with the controller-runtime metrics registry
+	metrics.Registry.MustRegister(
+		gameReady,
+		gameDesiredReplicas,
+		gameReadyReplicas,
+		gameTimeToFirstReady,
+		gameReconcileFailures,
+		gameUpgrades,
+	)
+}
+
+// gameMetricLabels returns the metric labels for a given CR
+func gameMetricLabels(cr *appsv1beta1.PacmanGame) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":   cr.Namespace,
+		"name":        cr.Name,
+		"app_version": gameAppVersion(cr),
+	}
+}
+
+// gameAppVersion returns the version of the Pacman image a given CR runs, the tag or digest of the image reference
+// it was resolved from. It's the requested app version until the image is resolved
+func gameAppVersion(cr *appsv1beta1.PacmanGame) string {
+	if cr.Status.AppImage == nil || cr.Status.AppImage.Reference == "" {
+		return getAppVersion(cr)
+	}
+	reference := cr.Status.AppImage.Reference
+	if i := strings.Index(reference, "@"); i >= 0 {
+		return reference[i+1:]
+	}
+	if repository := imageRepository(reference); len(repository) < len(reference) {
+		return reference[len(repository)+1:]
+	}
+	return "latest"
+}
+
+// seriesOf returns the series reported for a given CR, the metrics lock must be held
+func seriesOf(key types.NamespacedName) *gameSeries {
+	series, ok := gameMetricsSeries.games[key]
+	if !ok {
+		series = &gameSeries{failures: map[[2]string]bool{}, upgrades: map[string]bool{}}
+		gameMetricsSeries.games[key] = series
+	}
+	return series
+}
+
+// recordGameMetrics updates the metrics describing the state of a given CR rendered with given defaults
+func recordGameMetrics(cr *appsv1beta1.PacmanGame, defaults Defaults) {
+	key := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+	labels := gameMetricLabels(cr)
+
+	// Drop the series reported for a previous app version
+	gameMetricsSeries.Lock()
+	series := seriesOf(key)
+	if series.version != "" && series.version != labels["app_version"] {
+		deleteGameGauges(key, series.version)
+	}
+	series.version = labels["app_version"]
+	gameMetricsSeries.Unlock()
+
+	ready := 0.0
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeReady) {
+		ready = 1
+	}
+	gameReady.With(labels).Set(ready)
+
+	// Same replicas as the Pacman Deployment
+	desiredReplicas := cr.Spec.Replicas
+	if desiredReplicas == 0 {
+		desiredReplicas = defaults.PacmanReplicas
+	}
+	gameDesiredReplicas.With(labels).Set(float64(desiredReplicas))
+
+	readyReplicas := 0
+	for _, pod := range cr.Status.Pods {
+		if pod.Ready {
+			readyReplicas++
+		}
+	}
+	gameReadyReplicas.With(labels).Set(float64(readyReplicas))
+
+	if cr.Status.FirstReadyTime != nil {
+		gameTimeToFirstReady.With(labels).Set(cr.Status.FirstReadyTime.Sub(cr.CreationTimestamp.Time).Seconds())
+	}
+}
+
+// recordReconcileFailure counts a failed reconcile step of a given CR
+func recordReconcileFailure(cr *appsv1beta1.PacmanGame, step string) {
+	labels := gameMetricLabels(cr)
+	labels["step"] = step
+	gameMetricsSeries.Lock()
+	seriesOf(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}).failures[[2]string{labels["app_version"], step}] = true
+	gameMetricsSeries.Unlock()
+	gameReconcileFailures.With(labels).Inc()
+}
+
+// recordUpgrade counts a Pacman image upgrade of a given CR
+func recordUpgrade(cr *appsv1beta1.PacmanGame) {
+	labels := gameMetricLabels(cr)
+	gameMetricsSeries.Lock()
+	seriesOf(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}).upgrades[labels["app_version"]] = true
+	gameMetricsSeries.Unlock()
+	gameUpgrades.With(labels).Inc()
+}
+
+// deleteGameMetrics removes the metrics of a deleted CR, its counters included
+func deleteGameMetrics(key types.NamespacedName) {
+	gameMetricsSeries.Lock()
+	defer gameMetricsSeries.Unlock()
+	series, ok := gameMetricsSeries.games[key]
+	if !ok {
+		return
+	}
+	if series.version != "" {
+		deleteGameGauges(key, series.version)
+	}
+	for failure := range series.failures {
+		gameReconcileFailures.DeleteLabelValues(key.Namespace, key.Name, failure[0], failure[1])
+	}
+	for version := range series.upgrades {
+		gameUpgrades.DeleteLabelValues(key.Namespace, key.Name, version)
+	}
+	delete(gameMetricsSeries.games, key)
+}
+
+// deleteGameGauges removes the gauge series of a CR for a given app version. Counters are kept until the CR is
+// deleted, so the failures and upgrades are not lost for the version they happened on
+func deleteGameGauges(key types.NamespacedName, version string) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name, "app_version": version}
+	gameReady.Delete(labels)
+	gameDesiredReplicas.Delete(labels)
+	gameReadyReplicas.Delete(labels)
+	gameTimeToFirstReady.Delete(labels)
+}
@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRecordGameMetricsDesiredReplicas(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	defer deleteGameMetrics(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+	defaults := NewDefaults()
+	defaults.PacmanReplicas = 3
+
+	// Games not setting spec.replicas report the configured default, like their Deployment
+	recordGameMetrics(cr, defaults)
+	if got := testutil.ToFloat64(gameDesiredReplicas.With(gameMetricLabels(cr))); got != 3 {
+		t.Errorf("expected 3 desired replicas, got %v", got)
+	}
+
+	cr.Spec.Replicas = 2
+	recordGameMetrics(cr, defaults)
+	if got := testutil.ToFloat64(gameDesiredReplicas.With(gameMetricLabels(cr))); got != 2 {
+		t.Errorf("expected 2 desired replicas, got %v", got)
+	}
+}
+
+func TestGameAppVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		appVersion string
+		image      *appsv1beta1.AppImageStatus
+		want       string
+	}{
+		{"not resolved", "v0.0.2", nil, "v0.0.2"},
+		{"not resolved nor set", "", nil, "latest"},
+		{"tag override", "v0.0.2", &appsv1beta1.AppImageStatus{Reference: "quay.io/ifont/pacman-nodejs-app:v0.0.3"}, "v0.0.3"},
+		{"registry with port", "", &appsv1beta1.AppImageStatus{Reference: "registry.lab:5000/pacman:v1"}, "v1"},
+		{"digest override", "", &appsv1beta1.AppImageStatus{Reference: "registry.lab:5000/pacman@sha256:0123"}, "sha256:0123"},
+		{"no tag", "", &appsv1beta1.AppImageStatus{Reference: "registry.lab:5000/pacman"}, "latest"},
+	}
+	for _, test := range tests {
+		cr := &appsv1beta1.PacmanGame{}
+		cr.Spec.AppVersion = test.appVersion
+		cr.Status.AppImage = test.image
+		if got := gameAppVersion(cr); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
+
+func TestDeleteGameMetricsRemovesCounters(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "deleted-game", Namespace: "pacman"}}
+	cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: "quay.io/ifont/pacman-nodejs-app:v0.0.1"}
+	recordGameMetrics(cr, NewDefaults())
+	recordReconcileFailure(cr, "PacmanDeployment")
+	cr.Status.AppImage = &appsv1beta1.AppImageStatus{Reference: "quay.io/ifont/pacman-nodejs-app:v0.0.2"}
+	recordUpgrade(cr)
+	recordGameMetrics(cr, NewDefaults())
+	if got := testutil.ToFloat64(gameUpgrades.WithLabelValues("pacman", "deleted-game", "v0.0.2")); got != 1 {
+		t.Errorf("expected 1 upgrade, got %v", got)
+	}
+
+	deleteGameMetrics(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+	// Deleting a series that is already gone returns false
+	if gameReconcileFailures.DeleteLabelValues("pacman", "deleted-game", "v0.0.1", "PacmanDeployment") {
+		t.Errorf("expected the failures to be deleted")
+	}
+	if gameUpgrades.DeleteLabelValues("pacman", "deleted-game", "v0.0.2") {
+		t.Errorf("expected the upgrades to be deleted")
+	}
+	for _, version := range []string{"v0.0.1", "v0.0.2"} {
+		if gameReady.DeleteLabelValues("pacman", "deleted-game", version) {
+			t.Errorf("expected the ready gauge of %s to be deleted", version)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Kinds provided by the Prometheus Operator CRDs
+var (
+	serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+)
+
+// grafanaDashboardLabel is the label the Grafana dashboard sidecar uses to discover dashboard ConfigMaps
+const grafanaDashboardLabel = "grafana_dashboard"
+
+func (r *PacmanGameReconciler) reconcileMonitoring(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+	available, err := isMonitoringAvailable(r.RESTMapper())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Remove the monitoring objects left behind by a previous configuration
+	desired, obsolete := monitoringObjectsForCR(cr, available)
+	if getManagementPolicy(cr) == appsv1beta1.ManagementPolicyManaged {
+		for _, obj := range obsolete {
+			if err := r.deleteIfControlled(ctx, cr, obj, log); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	if !cr.Spec.Monitoring.Enabled {
+		setCondition(cr, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionTrue, appsv1beta1.ReasonMonitoringDisabled, "Monitoring is not enabled")
+		return ctrl.Result{}, nil
+	}
+
+	if !available {
+		log.Info("Prometheus Operator CRDs not found, skipping ServiceMonitors and PrometheusRule")
+	}
+	for _, obj := range desired {
+		// Set PacmanGame instance as the owner and controller of the object
+		if err := controllerutil.SetControllerReference(cr, obj, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Apply the object, this corrects any drift on the fields managed by the operator
+		if _, err := r.applyObject(ctx, cr, obj, log); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !available {
+		setCondition(cr, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionTrue, appsv1beta1.ReasonMonitoringUnavailable, "Prometheus Operator CRDs not found, only the dashboard is configured")
+	} else if !cr.Spec.Mongo.Exporter.Enabled {
+		// The Pacman app serves no metrics, the mongo exporter is the only scrape target
+		setCondition(cr, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionFalse, appsv1beta1.ReasonNoMetricsSource, "No metrics source is configured, enable spec.mongo.exporter to create the ServiceMonitor. The PrometheusRule and dashboard are configured")
+	} else {
+		setCondition(cr, appsv1beta1.ConditionTypeMonitoringReady, metav1.ConditionTrue, appsv1beta1.ReasonMonitoringConfigured, "ServiceMonitor, PrometheusRule and dashboard are configured")
+	}
+	// Monitoring reconcile finished
+	return ctrl.Result{}, nil
+}
+
+// monitoringObjectsForCR returns the monitoring objects a given CR needs and the ones it doesn't need anymore.
+// Without the Prometheus Operator CRDs only the dashboard can be created. The mongo ServiceMonitor needs
+// the exporter sidecar serving the metrics port
+func monitoringObjectsForCR(cr *appsv1beta1.PacmanGame, available bool) ([]client.Object, []client.Object) {
+	var desired, obsolete []client.Object
+	if cr.Spec.Monitoring.Enabled {
+		desired = append(desired, newDashboardConfigMapForCR(cr))
+	} else {
+		obsolete = append(obsolete, newDashboardConfigMapForCR(cr))
+	}
+	if !available {
+		return desired, obsolete
+	}
+	if cr.Spec.Monitoring.Enabled {
+		desired = append(desired, newPrometheusRuleForCR(cr))
+	} else {
+		obsolete = append(obsolete, newPrometheusRuleForCR(cr))
+	}
+	if cr.Spec.Monitoring.Enabled && cr.Spec.Mongo.Exporter.Enabled {
+		desired = append(desired, newMongoServiceMonitorForCR(cr))
+	} else {
+		obsolete = append(obsolete, newMongoServiceMonitorForCR(cr))
+	}
+	return desired, obsolete
+}
+
+// deleteIfControlled deletes a given object only if the cache holds it and it's controlled by the CR, so the
+// objects that were never created don't cost a delete request on every reconcile
+func (r *PacmanGameReconciler) deleteIfControlled(ctx context.Context, cr *appsv1beta1.PacmanGame, obj client.Object, log logr.Logger) error {
+	existing := obj.DeepCopyObject().(client.Object)
+	err := r.Cache.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(existing, cr) {
+		return nil
+	}
+	return r.deleteIfExists(ctx, existing, log)
+}
+
+// isMonitoringAvailable returns true if the Prometheus Operator CRDs are installed in the cluster
+func isMonitoringAvailable(mapper meta.RESTMapper) (bool, error) {
+	for _, gvk := range []schema.GroupVersionKind{serviceMonitorGVK, prometheusRuleGVK} {
+		_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Returns a new unstructured object for a kind not registered in the scheme
+func newUnstructuredForCR(cr *appsv1beta1.PacmanGame, gvk schema.GroupVersionKind, name string, component string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(cr.Namespace)
+	obj.SetLabels(labelsForCR(cr, component))
+	return obj
+}
+
+// Returns a new servicemonitor for the mongo exporter
+func newMongoServiceMonitorForCR(cr *appsv1beta1.PacmanGame) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				PacmanGameLabel: cr.Name,
+				ComponentLabel:  "mongo",
+			},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port":     "metrics",
+				"interval": getMonitoringInterval(cr),
+			},
+		},
+	}
+	return newUnstructuredForCR(cr, serviceMonitorGVK, "mongo-"+cr.Name, "mongo", spec)
+}
+
+// Returns a new prometheusrule with the alerts for the game, the mongo alert needs the exporter sidecar
+func newPrometheusRuleForCR(cr *appsv1beta1.PacmanGame) *unstructured.Unstructured {
+	gameSelector := fmt.Sprintf(`namespace="%s",name="%s"`, cr.Namespace, cr.Name)
+	podSelector := fmt.Sprintf(`namespace="%s",pod=~"(pacman|mongo)-%s-.*"`, cr.Namespace, cr.Name)
+	mongoSelector := fmt.Sprintf(`namespace="%s",service="mongo-%s"`, cr.Namespace, cr.Name)
+	rules := []interface{}{
+		newAlertRule("PacmanGameDown",
+			fmt.Sprintf("pacman_operator_game_ready{%s} == 0", gameSelector),
+			"5m", "critical",
+			fmt.Sprintf("PacmanGame %s/%s has not been ready for 5 minutes", cr.Namespace, cr.Name)),
+	}
+	if cr.Spec.Mongo.Exporter.Enabled {
+		rules = append(rules, newAlertRule("PacmanMongoDown",
+			fmt.Sprintf("mongodb_up{%s} == 0 or absent(mongodb_up{%s})", mongoSelector, mongoSelector),
+			"5m", "critical",
+			fmt.Sprintf("Mongo database of PacmanGame %s/%s has been down for 5 minutes", cr.Namespace, cr.Name)))
+	}
+	rules = append(rules, newAlertRule("PacmanGameCrashLooping",
+		fmt.Sprintf("increase(kube_pod_container_status_restarts_total{%s}[15m]) > 3", podSelector),
+		"5m", "warning",
+		fmt.Sprintf("A container of PacmanGame %s/%s is restarting frequently", cr.Namespace, cr.Name)))
+	spec := map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name":  "pacman-" + cr.Name,
+				"rules": rules,
+			},
+		},
+	}
+	return newUnstructuredForCR(cr, prometheusRuleGVK, "pacman-"+cr.Name, "pacman", spec)
+}
+
+// newAlertRule returns a prometheus alerting rule
+func newAlertRule(name string, expr string, duration string, severity string, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"for":   duration,
+		"labels": map[string]interface{}{
+			"severity": severity,
+		},
+		"annotations": map[string]interface{}{
+			"description": description,
+		},
+	}
+}
+
+// Returns a new configmap holding the grafana dashboard for the game
+func newDashboardConfigMapForCR(cr *appsv1beta1.PacmanGame) *corev1.ConfigMap {
+	labels := labelsForCR(cr, "pacman")
+	labels[grafanaDashboardLabel] = "1"
+	dashboard := strings.NewReplacer(
+		"${NAMESPACE}", cr.Namespace,
+		"${NAME}", cr.Name,
+	).Replace(dashboardTemplate)
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pacman-" + cr.Name + "-dashboard",
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			"pacman-" + cr.Name + ".json": dashboard,
+		},
+	}
+}
+
+// getMonitoringInterval returns the scrape interval configured for a given CR, defaulting to 30s
+func getMonitoringInterval(cr *appsv1beta1.PacmanGame) string {
+	if cr.Spec.Monitoring.Interval == "" {
+		return "30s"
+	}
+	return cr.Spec.Monitoring.Interval
+}
+
+// dashboardTemplate is the grafana dashboard for a game, ${NAMESPACE} and ${NAME} are replaced with the CR ones
+const dashboardTemplate = `{
+  "title": "Pacman ${NAMESPACE}/${NAME}",
+  "uid": "pacman-${NAMESPACE}-${NAME}",
+  "schemaVersion": 27,
+  "time": {"from": "now-6h", "to": "now"},
+  "panels": [
+    {
+      "id": 1,
+      "type": "stat",
+      "title": "Ready",
+      "gridPos": {"x": 0, "y": 0, "w": 6, "h": 6},
+      "targets": [{"expr": "max(pacman_operator_game_ready{namespace=\"${NAMESPACE}\",name=\"${NAME}\"})"}]
+    },
+    {
+      "id": 2,
+      "type": "timeseries",
+      "title": "Replicas",
+      "gridPos": {"x": 6, "y": 0, "w": 18, "h": 6},
+      "targets": [
+        {"expr": "max(pacman_operator_game_desired_replicas{namespace=\"${NAMESPACE}\",name=\"${NAME}\"})", "legendFormat": "desired"},
+        {"expr": "max(pacman_operator_game_ready_replicas{namespace=\"${NAMESPACE}\",name=\"${NAME}\"})", "legendFormat": "ready"}
+      ]
+    },
+    {
+      "id": 3,
+      "type": "timeseries",
+      "title": "Mongo connections",
+      "gridPos": {"x": 0, "y": 6, "w": 12, "h": 8},
+      "targets": [{"expr": "sum(mongodb_connections{namespace=\"${NAMESPACE}\",service=\"mongo-${NAME}\",state=\"current\"})", "legendFormat": "current"}]
+    },
+    {
+      "id": 4,
+      "type": "timeseries",
+      "title": "Mongo operations",
+      "gridPos": {"x": 12, "y": 6, "w": 12, "h": 8},
+      "targets": [{"expr": "sum by (type) (rate(mongodb_op_counters_total{namespace=\"${NAMESPACE}\",service=\"mongo-${NAME}\"}[5m]))", "legendFormat": "{{type}}"}]
+    },
+    {
+      "id": 5,
+      "type": "timeseries",
+      "title": "Container restarts",
+      "gridPos": {"x": 0, "y": 14, "w": 24, "h": 6},
+      "targets": [{"expr": "sum by (pod) (increase(kube_pod_container_status_restarts_total{namespace=\"${NAMESPACE}\",pod=~\"(pacman|mongo)-${NAME}-.*\"}[15m]))", "legendFormat": "{{pod}}"}]
+    }
+  ]
+}`
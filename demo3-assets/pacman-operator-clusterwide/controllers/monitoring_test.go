@@ -0,0 +1,156 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// deleteCounter counts the delete requests sent through a client
+type deleteCounter struct {
+	client.Client
+	deletes int
+}
+
+func (c *deleteCounter) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.deletes++
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// objectNames returns the kind/name of the given objects
+func objectNames(objects []client.Object) []string {
+	names := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		names = append(names, kind+"/"+obj.GetName())
+	}
+	return names
+}
+
+// alertNames returns the names of the alerts of a PrometheusRule
+func alertNames(t *testing.T, rule *unstructured.Unstructured) []string {
+	groups, _, err := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("unexpected rule groups %v: %v", groups, err)
+	}
+	var names []string
+	for _, r := range groups[0].(map[string]interface{})["rules"].([]interface{}) {
+		names = append(names, r.(map[string]interface{})["alert"].(string))
+	}
+	return names
+}
+
+func TestMonitoringObjectsWithoutMongoExporter(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	cr.Spec.Monitoring.Enabled = true
+
+	desired, obsolete := monitoringObjectsForCR(cr, true)
+	if names := strings.Join(objectNames(desired), ","); names != "ConfigMap/pacman-game-dashboard,PrometheusRule/pacman-game" {
+		t.Errorf("unexpected desired objects %s", names)
+	}
+	if names := strings.Join(objectNames(obsolete), ","); names != "ServiceMonitor/mongo-game" {
+		t.Errorf("unexpected obsolete objects %s", names)
+	}
+	// No container serves the mongo metrics, the mongo alert would always fire
+	if alerts := strings.Join(alertNames(t, newPrometheusRuleForCR(cr)), ","); alerts != "PacmanGameDown,PacmanGameCrashLooping" {
+		t.Errorf("unexpected alerts %s", alerts)
+	}
+}
+
+func TestMonitoringObjectsWithMongoExporter(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	cr.Spec.Monitoring.Enabled = true
+	cr.Spec.Mongo.Exporter.Enabled = true
+
+	desired, _ := monitoringObjectsForCR(cr, true)
+	if names := strings.Join(objectNames(desired), ","); names != "ConfigMap/pacman-game-dashboard,PrometheusRule/pacman-game,ServiceMonitor/mongo-game" {
+		t.Errorf("unexpected desired objects %s", names)
+	}
+	if alerts := strings.Join(alertNames(t, newPrometheusRuleForCR(cr)), ","); alerts != "PacmanGameDown,PacmanMongoDown,PacmanGameCrashLooping" {
+		t.Errorf("unexpected alerts %s", alerts)
+	}
+
+	// Without the Prometheus Operator CRDs only the dashboard is handled
+	desired, obsolete := monitoringObjectsForCR(cr, false)
+	if names := strings.Join(objectNames(desired), ","); names != "ConfigMap/pacman-game-dashboard" || len(obsolete) != 0 {
+		t.Errorf("unexpected objects %s and %v", names, objectNames(obsolete))
+	}
+}
+
+func TestIsMonitoringAvailable(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+	if available, err := isMonitoringAvailable(mapper); err != nil || available {
+		t.Errorf("expected the PrometheusRule CRD to be missing, got %v, %v", available, err)
+	}
+
+	mapper.Add(prometheusRuleGVK, meta.RESTScopeNamespace)
+	if available, err := isMonitoringAvailable(mapper); err != nil || !available {
+		t.Errorf("expected the Prometheus Operator CRDs to be installed, got %v, %v", available, err)
+	}
+}
+
+func TestDeleteIfControlled(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(testScheme))
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman", UID: "game-uid"}}
+	rule := newPrometheusRuleForCR(cr)
+	utilruntime.Must(controllerutil.SetControllerReference(cr, rule, testScheme))
+	// A dashboard created by someone else with the name the operator would use
+	dashboard := newDashboardConfigMapForCR(cr)
+	apiClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(rule, dashboard).Build()
+	counter := &deleteCounter{Client: apiClient}
+	r := &PacmanGameReconciler{Client: counter, Cache: apiClient, Scheme: testScheme}
+
+	ctx := context.Background()
+	// The objects that were never created aren't deleted
+	if err := r.deleteIfControlled(ctx, cr, newMongoServiceMonitorForCR(cr), logr.Discard()); err != nil || counter.deletes != 0 {
+		t.Errorf("expected no delete request for a missing object, got %d: %v", counter.deletes, err)
+	}
+	// The objects not controlled by the game are kept
+	if err := r.deleteIfControlled(ctx, cr, newDashboardConfigMapForCR(cr), logr.Discard()); err != nil || counter.deletes != 0 {
+		t.Errorf("expected no delete request for an object not controlled by the game, got %d: %v", counter.deletes, err)
+	}
+	if err := apiClient.Get(ctx, client.ObjectKeyFromObject(dashboard), &corev1.ConfigMap{}); err != nil {
+		t.Errorf("expected the dashboard to be kept: %v", err)
+	}
+	// The objects the game created are deleted
+	if err := r.deleteIfControlled(ctx, cr, newPrometheusRuleForCR(cr), logr.Discard()); err != nil || counter.deletes != 1 {
+		t.Errorf("expected the rule to be deleted, got %d deletes: %v", counter.deletes, err)
+	}
+	existing := newPrometheusRuleForCR(cr)
+	if err := apiClient.Get(ctx, client.ObjectKeyFromObject(existing), existing); !errors.IsNotFound(err) {
+		t.Errorf("expected the rule to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// isNamespaceSelected returns true if the PacmanGames in a given namespace have to be reconciled
+func (r *PacmanGameReconciler) isNamespaceSelected(ctx context.Context, name string) (bool, error) {
+	if r.NamespaceSelector == nil || r.NamespaceSelector.Empty() {
+		return true, nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, namespace); err != nil {
+		return false, err
+	}
+	return r.NamespaceSelector.Matches(labels.Set(namespace.GetLabels())), nil
+}
+
+// mapNamespaceToPacmanGames returns a reconcile request for every PacmanGame in a given namespace,
+// so the games are picked up or left alone as soon as the namespace gains or loses the selected labels
+func (r *PacmanGameReconciler) mapNamespaceToPacmanGames(obj client.Object) []reconcile.Request {
+	return r.requestsForPacmanGames(client.InNamespace(obj.GetName()))
+}
@@ -0,0 +1,137 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newNamespaceTestClient(objs ...client.Object) client.Client {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(testScheme))
+	return fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).Build()
+}
+
+func TestIsNamespaceSelected(t *testing.T) {
+	arcade := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade", Labels: map[string]string{"team": "arcade"}}}
+	puzzle := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "puzzle", Labels: map[string]string{"team": "puzzle"}}}
+	apiClient := newNamespaceTestClient(arcade, puzzle)
+
+	tests := []struct {
+		name      string
+		selector  labels.Selector
+		namespace string
+		want      bool
+		wantErr   bool
+	}{
+		{name: "no selector", selector: nil, namespace: "puzzle", want: true},
+		{name: "empty selector", selector: labels.Everything(), namespace: "puzzle", want: true},
+		{name: "empty selector and missing namespace", selector: labels.Everything(), namespace: "missing", want: true},
+		{name: "matching selector", selector: labels.SelectorFromSet(labels.Set{"team": "arcade"}), namespace: "arcade", want: true},
+		{name: "non-matching selector", selector: labels.SelectorFromSet(labels.Set{"team": "arcade"}), namespace: "puzzle", want: false},
+		{name: "missing namespace", selector: labels.SelectorFromSet(labels.Set{"team": "arcade"}), namespace: "missing", wantErr: true},
+	}
+	for _, test := range tests {
+		r := &PacmanGameReconciler{Client: apiClient, NamespaceSelector: test.selector}
+		got, err := r.isNamespaceSelected(context.Background(), test.namespace)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestMapNamespaceToPacmanGames(t *testing.T) {
+	apiClient := newNamespaceTestClient(
+		&appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game-1", Namespace: "arcade"}},
+		&appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game-2", Namespace: "arcade"}},
+		&appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game-1", Namespace: "puzzle"}},
+	)
+	r := &PacmanGameReconciler{Client: apiClient, NamespaceSelector: labels.SelectorFromSet(labels.Set{"team": "arcade"})}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []reconcile.Request
+	}{
+		{name: "namespace with games", namespace: "arcade", want: []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Namespace: "arcade", Name: "game-1"}},
+			{NamespacedName: types.NamespacedName{Namespace: "arcade", Name: "game-2"}},
+		}},
+		{name: "namespace without games", namespace: "retro", want: []reconcile.Request{}},
+	}
+	for _, test := range tests {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: test.namespace}}
+		if got := r.mapNamespaceToPacmanGames(namespace); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestNamespaceLabelChangeTriggersMapping(t *testing.T) {
+	// The Namespace watch only maps the updates changing the namespace labels to the games
+	tests := []struct {
+		name   string
+		old    *corev1.Namespace
+		new    *corev1.Namespace
+		mapped bool
+	}{
+		{
+			name:   "label added",
+			old:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade"}},
+			new:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade", Labels: map[string]string{"team": "arcade"}}},
+			mapped: true,
+		},
+		{
+			name:   "label removed",
+			old:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade", Labels: map[string]string{"team": "arcade"}}},
+			new:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade"}},
+			mapped: true,
+		},
+		{
+			name:   "annotation changed",
+			old:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade", Labels: map[string]string{"team": "arcade"}}},
+			new:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "arcade", Labels: map[string]string{"team": "arcade"}, Annotations: map[string]string{"owner": "someone"}}},
+			mapped: false,
+		},
+	}
+	for _, test := range tests {
+		if got := (predicate.LabelChangedPredicate{}).Update(event.UpdateEvent{ObjectOld: test.old, ObjectNew: test.new}); got != test.mapped {
+			t.Errorf("%s: expected mapped %v, got %v", test.name, test.mapped, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resolveDefaults returns the defaults the game objects are rendered with, the PacmanOperatorConfig values
+// override the reconciler ones. It also returns the PacmanOperatorConfig generation, 0 when it doesn't exist
+func (r *PacmanGameReconciler) resolveDefaults(ctx context.Context) (Defaults, int64, error) {
+	defaults := NewDefaults().Override(r.Defaults)
+	config := &appsv1beta1.PacmanOperatorConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Name: appsv1beta1.PacmanOperatorConfigName}, config); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return defaults, 0, nil
+		}
+		return defaults, 0, err
+	}
+	return defaults.Override(defaultsFromOperatorConfig(config.Spec)), config.Generation, nil
+}
+
+// isOperatorConfigAvailable returns true if the PacmanOperatorConfig CRD is installed in the cluster
+func isOperatorConfigAvailable(mapper meta.RESTMapper) (bool, error) {
+	gvk := appsv1beta1.GroupVersion.WithKind("PacmanOperatorConfig")
+	_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isOperatorConfig returns true if a given object is the PacmanOperatorConfig read by the operator
+func isOperatorConfig(obj client.Object) bool {
+	return obj.GetName() == appsv1beta1.PacmanOperatorConfigName
+}
+
+// mapOperatorConfigToPacmanGames returns a reconcile request for every PacmanGame
+func (r *PacmanGameReconciler) mapOperatorConfigToPacmanGames(obj client.Object) []reconcile.Request {
+	return r.requestsForPacmanGames()
+}
+
+// requestsForPacmanGames returns a reconcile request for every PacmanGame matching the given list options
+func (r *PacmanGameReconciler) requestsForPacmanGames(opts ...client.ListOption) []reconcile.Request {
+	// Map functions don't receive a context, the list is served from the cache
+	ctx := context.Background()
+	games := &appsv1beta1.PacmanGameList{}
+	if err := r.List(ctx, games, opts...); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "Failed to list PacmanGames")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(games.Items))
+	for _, game := range games.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: game.Name, Namespace: game.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,37 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func TestIsOperatorConfigAvailable(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(appsv1beta1.GroupVersion.WithKind("PacmanGame"), meta.RESTScopeNamespace)
+	if available, err := isOperatorConfigAvailable(mapper); err != nil || available {
+		t.Errorf("expected the PacmanOperatorConfig CRD to be missing, got %v, %v", available, err)
+	}
+
+	mapper.Add(appsv1beta1.GroupVersion.WithKind("PacmanOperatorConfig"), meta.RESTScopeRoot)
+	if available, err := isOperatorConfigAvailable(mapper); err != nil || !available {
+		t.Errorf("expected the PacmanOperatorConfig CRD to be installed, got %v, %v", available, err)
+	}
+}
@@ -18,44 +18,154 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"github.com/mvazquezc/pacman-operator/internal/cosign"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // PacmanGameReconciler reconciles a PacmanGame object
 type PacmanGameReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Cache reads the objects watched by the controller, the obsolete objects are only deleted when it holds them
+	Cache client.Reader
+	// ClusterRBACEnabled tells whether the operator has been granted the permissions to manage
+	// ClusterRoles and ClusterRoleBindings for games running in Cluster RBAC scope
+	ClusterRBACEnabled bool
+	// RateLimiter limits how often failed reconciles are retried, the controller-runtime default is used when nil
+	RateLimiter workqueue.RateLimiter
+	// NamespaceSelector restricts the reconciled PacmanGames to the namespaces matching it, every namespace when nil
+	NamespaceSelector labels.Selector
+	// Defaults are used to render the objects of the games not setting them, the operator defaults when unset
+	Defaults Defaults
+	// Registry looks up the Pacman image tags so missing tags aren't rolled out, the games are deployed without
+	// checking the registry when nil
+	Registry registry.Resolver
+	// ResolveImageDigests deploys the games by the digest their Pacman image tag resolves to instead of by tag
+	ResolveImageDigests bool
+	// ImagePolicy lists the repositories whose Pacman images must be signed, no signature is required when nil
+	ImagePolicy *cosign.Policy
+
+	// verifiedImages holds the key every verified image is signed with, keyed by the image digest
+	verifiedImages *utilcache.LRUExpireCache
 }
 
 // Finalizer for our objects
 const PacmanGameFinalizer = "finalizer.pacmangame.apps.rha.lab"
 
+// FieldManager is the server-side apply field manager used for the objects managed by the operator
+const FieldManager = "pacman-operator"
+
+// Reasons of the events emitted on the PacmanGame
+const (
+	EventReasonCreated           = "Created"
+	EventReasonUpdated           = "Updated"
+	EventReasonImageUpgraded     = "ImageUpgraded"
+	EventReasonScaled            = "Scaled"
+	EventReasonFinalizerAdded    = "FinalizerAdded"
+	EventReasonFinalizing        = "Finalizing"
+	EventReasonFinalized         = "Finalized"
+	EventReasonReconcileFailed   = "ReconcileFailed"
+	EventReasonSuspended         = "Suspended"
+	EventReasonResumed           = "Resumed"
+	EventReasonDriftDetected     = "DriftDetected"
+	EventReasonInvalidSpec       = "InvalidSpec"
+	EventReasonInvalidAppVersion = "InvalidAppVersion"
+	EventReasonImageRejected     = "ImageRejected"
+)
+
+// Credentials of the game mongo database
+const (
+	mongoUser     = "admin"
+	mongoPassword = "admin"
+)
+
+// Port of the mongo metrics exporter sidecar
+const mongoExporterPort = 9216
+
+// Labels set on the objects managed by the operator
+const (
+	// ManagedByLabel identifies the objects managed by the operator, its value is FieldManager
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// ComponentLabel tells whether an object belongs to the mongo or the pacman component of a game
+	ComponentLabel = "app.kubernetes.io/component"
+	// PacmanGameLabel holds the name of the PacmanGame an object belongs to
+	PacmanGameLabel = "apps.rha.lab/pacmangame"
+	// PacmanGameOwnerAnnotation holds the namespace/name of the PacmanGame a cluster scoped object belongs to,
+	// cluster scoped objects can't have an owner reference to a namespaced PacmanGame
+	PacmanGameOwnerAnnotation = "apps.rha.lab/owner"
+)
+
 // +kubebuilder:rbac:groups=apps.rha.lab,resources=pacmangames,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.rha.lab,resources=pacmangames/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps.rha.lab,resources=pacmangames/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.rha.lab,resources=pacmanoperatorconfigs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// The ClusterRole and ClusterRoleBinding permissions live in the clusterrbac package so they can be left out
 
 func (r *PacmanGameReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Every reconcile is traced, the API calls and reconcile steps are recorded as child spans
+	ctx, span := tracing.StartSpan(ctx, "PacmanGame.Reconcile",
+		attribute.String("pacmangame.namespace", req.Namespace),
+		attribute.String("pacmangame.name", req.Name))
+	defer span.End()
+	result, err := r.reconcile(ctx, req)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (r *PacmanGameReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
 
 	// Fetch the PacmanGame instance
@@ -67,12 +177,16 @@ func (r *PacmanGameReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			log.Info("PacmanGame resource not found. Ignoring since object must be deleted")
+			deleteGameMetrics(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		log.Error(err, "Failed to get PacmanGame")
 		return ctrl.Result{}, err
 	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("pacmangame.generation", instance.Generation))
+	// The status is computed along the reconcile and written once at the end
+	originalStatus := instance.Status.DeepCopy()
 
 	// Check if the CR is marked to be deleted
 	isInstanceMarkedToBeDeleted := instance.GetDeletionTimestamp() != nil
@@ -80,12 +194,15 @@ func (r *PacmanGameReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.Info("Instance marked for deletion, running finalizers")
 		if contains(instance.GetFinalizers(), PacmanGameFinalizer) {
 			// Run the finalizer logic
-			err := r.finalizePacmanGame(log, instance)
+			r.recordEvent(instance, corev1.EventTypeNormal, EventReasonFinalizing, "Running finalizer "+PacmanGameFinalizer)
+			err := r.finalizePacmanGame(ctx, log, instance)
 			if err != nil {
 				// Don't remove the finalizer if we failed to finalize the object
+				r.recordEvent(instance, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to finalize PacmanGame: "+err.Error())
 				return ctrl.Result{}, err
 			}
 			log.Info("Instance finalizers completed")
+			r.recordEvent(instance, corev1.EventTypeNormal, EventReasonFinalized, "Finalizer "+PacmanGameFinalizer+" completed")
 			// Remove finalizer once the finalizer logic has run
 			controllerutil.RemoveFinalizer(instance, PacmanGameFinalizer)
 			err = r.Update(ctx, instance)
@@ -95,158 +212,267 @@ func (r *PacmanGameReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 		log.Info("Instance can be deleted now")
+		deleteGameMetrics(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	// Games in namespaces not matching the namespace selector are left alone, they are still finalized above
+	selected, err := r.isNamespaceSelected(ctx, instance.Namespace)
+	if err != nil {
+		log.Error(err, "Failed to get the PacmanGame Namespace")
+		return ctrl.Result{}, err
+	}
+	if !selected {
+		log.Info("PacmanGame Namespace doesn't match the namespace selector, skipping reconcile")
 		return ctrl.Result{}, nil
 	}
 
 	// Add Finalizers to the CR
 	if !contains(instance.GetFinalizers(), PacmanGameFinalizer) {
-		if err := r.addFinalizer(log, instance); err != nil {
+		if err := r.addFinalizer(ctx, log, instance); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	// Reconcile Mongo Deployment object
-	result, err := r.reconcileMongoDeployment(instance, log)
-	if err != nil {
-		return result, err
+	// Suspended games are not reconciled until they are resumed
+	if instance.Spec.Suspend {
+		log.Info("PacmanGame reconciliation is suspended")
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, appsv1beta1.ConditionTypeSuspended) {
+			r.recordEvent(instance, corev1.EventTypeNormal, EventReasonSuspended, "Reconciliation suspended")
+		}
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeSuspended, Status: metav1.ConditionTrue, Reason: "ReconcileSuspended", Message: "Reconciliation is suspended through spec.suspend"})
+		return ctrl.Result{}, r.patchPacmanGameStatus(ctx, instance, originalStatus, log)
 	}
-	// Reconcile Mongo Service object
-	result, err = r.reconcileMongoService(instance, log)
-	if err != nil {
-		return result, err
+	// Resuming the game runs a full reconcile of all its objects
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, appsv1beta1.ConditionTypeSuspended) {
+		log.Info("PacmanGame reconciliation resumed")
+		r.recordEvent(instance, corev1.EventTypeNormal, EventReasonResumed, "Reconciliation resumed")
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeSuspended, Status: metav1.ConditionFalse, Reason: "ReconcileResumed", Message: "Reconciliation has been resumed"})
 	}
 
-	// Reconcile Pacman Deployment object
-	result, err = r.reconcilePacmanDeployment(instance, log)
-	if err != nil {
-		return result, err
-	}
-	// Reconcile Pacman Service object
-	result, err = r.reconcilePacmanService(instance, log)
-	if err != nil {
-		return result, err
+	// Unmanaged games are left alone
+	if getManagementPolicy(instance) == appsv1beta1.ManagementPolicyUnmanaged {
+		log.Info("PacmanGame is unmanaged, skipping reconcile")
+		r.setDriftStatus(instance)
+		return ctrl.Result{}, r.patchPacmanGameStatus(ctx, instance, originalStatus, log)
 	}
 
-	// Reconcile Pacman ServiceAccount object
-	result, err = r.reconcilePacmanServiceAccount(instance, log)
-	if err != nil {
-		return result, err
+	// Invalid specs the CRD schema can't reject are reported and not rendered, fixing them triggers a new reconcile
+	if err := validatePacmanGame(instance); err != nil {
+		log.Info("PacmanGame spec is invalid", "error", err.Error())
+		if !isSpecInvalid(instance) {
+			r.recordEvent(instance, corev1.EventTypeWarning, EventReasonInvalidSpec, err.Error())
+		}
+		setInvalidSpecConditions(instance, err)
+		return ctrl.Result{}, r.patchPacmanGameStatus(ctx, instance, originalStatus, log)
 	}
-	// Reconcile Pacman ClusterRole object
-	result, err = r.reconcilePacmanClusterRole(instance, log)
+
+	// The cluster wide defaults are resolved once, so every object is rendered with the same PacmanOperatorConfig
+	defaults, configGeneration, err := r.resolveDefaults(ctx)
 	if err != nil {
-		return result, err
+		log.Error(err, "Failed to get PacmanOperatorConfig")
+		return ctrl.Result{}, err
 	}
 
-	// Reconcile Pacman ClusterRoleBinding object
-	result, err = r.reconcilePacmanClusterRoleBinding(instance, log)
-	if err != nil {
-		return result, err
+	// Components are reconciled following their dependencies, every component is reconciled
+	// even if another one failed so each of them reports its own state
+	result, errs := r.runComponents(ctx, instance, r.components(), defaults, log)
+	if len(errs) == 0 {
+		instance.Status.ConfigGeneration = configGeneration
 	}
 
-	// The CR status is updated in the Deployment reconcile method
+	// The component conditions are set by each reconcile method, Ready is an aggregate of them
+	setAggregateConditions(instance)
+	recordGameMetrics(instance, defaults)
+	// The drift is reported once every object has been observed
+	r.setDriftStatus(instance)
+	if err := r.patchPacmanGameStatus(ctx, instance, originalStatus, log); err != nil {
+		errs = append(errs, err)
+	}
 
-	return ctrl.Result{}, nil
+	return result, utilerrors.NewAggregate(errs)
 }
 
 func (r *PacmanGameReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// The verified images are shared by every reconcile of the controller
+	r.verifiedImages = utilcache.NewLRUExpireCache(maxVerifiedImages)
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&appsv1beta1.PacmanGame{}).
+		WithOptions(controller.Options{RateLimiter: r.RateLimiter}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.ServiceAccount{}).
-		Owns(&rbacv1.ClusterRole{}).
-		Owns(&rbacv1.ClusterRoleBinding{}).
-		Complete(r)
+		Owns(&corev1.ConfigMap{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
+		// Pods are owned by ReplicaSets, so they are mapped back to their PacmanGame through labels
+		Watches(&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(mapObjectToPacmanGame),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isManagedByOperator)))
+	// Every game is rendered with the PacmanOperatorConfig defaults, so they are all reconciled when it changes.
+	// Without the PacmanOperatorConfig CRD the games are rendered with the operator defaults, and there's nothing to watch
+	operatorConfigAvailable, err := isOperatorConfigAvailable(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	if operatorConfigAvailable {
+		controllerBuilder = controllerBuilder.
+			Watches(&source.Kind{Type: &appsv1beta1.PacmanOperatorConfig{}},
+				handler.EnqueueRequestsFromMapFunc(r.mapOperatorConfigToPacmanGames),
+				builder.WithPredicates(predicate.GenerationChangedPredicate{}, predicate.NewPredicateFuncs(isOperatorConfig)))
+	} else {
+		mgr.GetLogger().Info("PacmanOperatorConfig CRD not installed, the games are rendered with the operator defaults")
+	}
+	// The monitoring objects can only be watched when the Prometheus Operator CRDs are installed
+	monitoringAvailable, err := isMonitoringAvailable(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	if monitoringAvailable {
+		for _, gvk := range []schema.GroupVersionKind{serviceMonitorGVK, prometheusRuleGVK} {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			controllerBuilder = controllerBuilder.Owns(obj)
+		}
+	}
+	// Namespaces are only watched to pick up the games of the namespaces gaining or losing the selected labels
+	if r.NamespaceSelector != nil && !r.NamespaceSelector.Empty() {
+		controllerBuilder = controllerBuilder.
+			Watches(&source.Kind{Type: &corev1.Namespace{}},
+				handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToPacmanGames),
+				builder.WithPredicates(predicate.LabelChangedPredicate{}))
+	}
+	// Cluster RBAC objects can only be watched when the operator has been granted access to them
+	// They have no owner reference, so they are mapped back to their PacmanGame through the owner annotation
+	if r.ClusterRBACEnabled {
+		controllerBuilder = controllerBuilder.
+			Watches(&source.Kind{Type: &rbacv1.ClusterRole{}},
+				handler.EnqueueRequestsFromMapFunc(mapClusterObjectToPacmanGame),
+				builder.WithPredicates(predicate.NewPredicateFuncs(isManagedByOperator))).
+			Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}},
+				handler.EnqueueRequestsFromMapFunc(mapClusterObjectToPacmanGame),
+				builder.WithPredicates(predicate.NewPredicateFuncs(isManagedByOperator)))
+	}
+	return controllerBuilder.Complete(r)
+}
+
+// mapObjectToPacmanGame returns a reconcile request for the PacmanGame a given object belongs to
+func mapObjectToPacmanGame(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[PacmanGameLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}},
+	}
 }
 
-func (r *PacmanGameReconciler) reconcilePacmanDeployment(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+// mapClusterObjectToPacmanGame returns a reconcile request for the PacmanGame a given cluster scoped object belongs to
+func mapClusterObjectToPacmanGame(obj client.Object) []reconcile.Request {
+	namespace, name, err := cache.SplitMetaNamespaceKey(obj.GetAnnotations()[PacmanGameOwnerAnnotation])
+	if err != nil || namespace == "" || name == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}},
+	}
+}
+
+// isManagedByOperator returns true if a given object is managed by the operator
+func isManagedByOperator(obj client.Object) bool {
+	return obj.GetLabels()[ManagedByLabel] == FieldManager
+}
+
+func (r *PacmanGameReconciler) reconcilePacmanDeployment(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+	// Resolve the Pacman image to a digest so every pod runs the same image
+	runningImage := cr.Status.AppImage.DeepCopy()
+	image, result, err := r.resolvePacmanImage(ctx, cr, defaults, log)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeInvalidAppVersion) {
+		// The requested tag doesn't exist, the Deployment is left untouched
+		return result, nil
+	}
+	verifyResult, err := r.verifyPacmanImage(ctx, cr, image, log)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	result = mergeResults(result, verifyResult)
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeImageRejected) {
+		// The Deployment is left untouched, the status keeps describing the image it runs
+		cr.Status.AppImage = runningImage
+		return result, nil
+	}
 	// Define a new Deployment object
-	deployment := newPacmanDeploymentForCR(cr)
+	deployment := newPacmanDeploymentForCR(cr, defaults, image)
 
 	// Set PacmanGame instance as the owner and controller of the Deployment
 	if err := ctrl.SetControllerReference(cr, deployment, r.Scheme); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Check if this Deployment already exists
-	deploymentFound := &appsv1.Deployment{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, deploymentFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
-		err = r.Create(context.Background(), deployment)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// Requeue the object to update its status
-		return ctrl.Result{Requeue: true}, nil
-	} else if err != nil {
+	// Leave the replicas to the HorizontalPodAutoscaler if the Deployment is being autoscaled
+	autoscaled, err := r.isAutoscaled(ctx, deployment)
+	if err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// Deployment already exists
-		log.Info("Deployment already exists", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
 	}
-
-	// Ensure deployment replicas match the desired state
-	if !reflect.DeepEqual(deploymentFound.Spec.Replicas, deployment.Spec.Replicas) {
-		log.Info("Current deployment replicas do not match PacmanGame configured Replicas")
-		// Update the replicas
-		err = r.Update(context.Background(), deployment)
-		if err != nil {
-			log.Error(err, "Failed to update Deployment.", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
-			return ctrl.Result{}, err
-		}
+	// Leave them as well to whoever scaled the Deployment when the game doesn't set them, the operator takes them
+	// back when spec.replicas is set
+	current := &appsv1.Deployment{}
+	err = r.Get(ctx, client.ObjectKeyFromObject(deployment), current)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
 	}
-	// Ensure deployment container image match the desired state, returns true if deployment needs to be updated
-	if checkDeploymentImage(deploymentFound, deployment) {
-		log.Info("Current deployment image version do not match PacmanGame configured version")
-		// Update the image
-		err = r.Update(context.Background(), deployment)
-		if err != nil {
-			log.Error(err, "Failed to update Deployment.", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
-			return ctrl.Result{}, err
-		}
+	scaledExternally, err := isScaledExternally(current)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if autoscaled || (cr.Spec.Replicas == 0 && scaledExternally) {
+		deployment.Spec.Replicas = nil
 	}
 
-	// Check if the deployment is ready
-	deploymentReady := isDeploymentReady(deploymentFound)
+	// Apply the Deployment, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, deployment, log); err != nil {
+		return ctrl.Result{}, err
+	}
 
+	if _, err := r.reconcilePacmanPodStatus(ctx, cr, defaults, log); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Update the frontend condition from the deployment readiness
+	setDeploymentCondition(cr, appsv1beta1.ConditionTypeFrontendReady, deployment)
+	if isDeploymentReady(deployment) {
+		setCondition(cr, appsv1beta1.ConditionTypePacmanGameDeploymentNotReady, metav1.ConditionFalse, appsv1beta1.ReasonDeploymentAvailable, "Pacman Deployment is ready")
+	} else {
+		setCondition(cr, appsv1beta1.ConditionTypePacmanGameDeploymentNotReady, metav1.ConditionTrue, appsv1beta1.ReasonDeploymentProgressing, "Pacman Deployment is not ready")
+	}
+	// Deployment reconcile finished
+	return result, nil
+}
+
+// reconcilePacmanPodStatus reports the Pacman pods in the CR status. It also runs while the Pacman Deployment
+// waits for its dependencies, so the status doesn't keep describing pods that are gone
+func (r *PacmanGameReconciler) reconcilePacmanPodStatus(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
 	// Create list options for listing deployment pods
 	podList := &corev1.PodList{}
 	listOpts := []client.ListOption{
-		client.InNamespace(deploymentFound.Namespace),
-		client.MatchingLabels(deploymentFound.Labels),
+		client.InNamespace(cr.Namespace),
+		client.MatchingLabels(labelsForCR(cr, "pacman")),
 	}
 	// List the pods for this PacmanGame deployment
-	err = r.List(context.Background(), podList, listOpts...)
+	err := r.List(ctx, podList, listOpts...)
 	if err != nil {
-		log.Error(err, "Failed to list Pods.", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
+		log.Error(err, "Failed to list Pods.", "Namespace", cr.Namespace, "PacmanGame", cr.Name)
 		return ctrl.Result{}, err
 	}
 	// Get running Pods from listing above (if any)
-	podNames := getRunningPodNames(podList.Items)
-	if deploymentReady {
-		// Update the status to ready
-		cr.Status.AppPods = podNames
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypePacmanGameDeploymentNotReady, Status: metav1.ConditionFalse, Reason: appsv1beta1.ConditionTypePacmanGameDeploymentNotReady})
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeReady, Status: metav1.ConditionTrue, Reason: appsv1beta1.ConditionTypeReady})
-	} else {
-		// Update the status to not ready
-		cr.Status.AppPods = podNames
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypePacmanGameDeploymentNotReady, Status: metav1.ConditionTrue, Reason: appsv1beta1.ConditionTypePacmanGameDeploymentNotReady})
-		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeReady, Status: metav1.ConditionFalse, Reason: appsv1beta1.ConditionTypeReady})
-	}
-	// Reconcile the new status for the instance
-	cr, err = r.updatePacmanGameStatus(cr, log)
-	if err != nil {
-		log.Error(err, "Failed to update PacmanGame Status.")
-		return ctrl.Result{}, err
-	}
-	// Deployment reconcile finished
+	cr.Status.AppPods = getRunningPodNames(podList.Items)
+	cr.Status.Pods = getPodStatuses(podList.Items)
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcilePacmanServiceAccount(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcilePacmanServiceAccount(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
 	// Define a new ServiceAccount object
 	serviceAccount := newPacmanServiceAccountForCR(cr)
 
@@ -255,28 +481,100 @@ func (r *PacmanGameReconciler) reconcilePacmanServiceAccount(cr *appsv1beta1.Pac
 		return ctrl.Result{}, err
 	}
 
-	// Check if this ServiceAccount already exists
-	serviceAccountFound := &corev1.ServiceAccount{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, serviceAccountFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
-		err = r.Create(context.Background(), serviceAccount)
+	// Apply the ServiceAccount, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, serviceAccount, log); err != nil {
+		return ctrl.Result{}, err
+	}
+	// ServiceAccount reconcile finished
+	return ctrl.Result{}, nil
+}
+
+func (r *PacmanGameReconciler) reconcilePacmanRBAC(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
+	if getRBACScope(cr) == appsv1beta1.RBACScopeNamespace {
+		// Reconcile Pacman Role object
+		result, err := r.reconcilePacmanRole(ctx, cr, log)
 		if err != nil {
-			return ctrl.Result{}, err
+			return result, err
+		}
+		// Reconcile Pacman RoleBinding object
+		result, err = r.reconcilePacmanRoleBinding(ctx, cr, log)
+		if err != nil {
+			return result, err
 		}
-		// ServiceAccount created successfully - don't requeue
+		// Remove the cluster RBAC objects left behind by a previous Cluster scope
+		if r.ClusterRBACEnabled && getManagementPolicy(cr) == appsv1beta1.ManagementPolicyManaged {
+			if err := r.deletePacmanClusterRBAC(ctx, cr, log); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		setCondition(cr, appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, appsv1beta1.ReasonRBACConfigured, "Pacman ServiceAccount can read the pods in the namespace")
+		// RBAC reconcile finished
 		return ctrl.Result{}, nil
-	} else if err != nil {
+	}
+
+	if !r.ClusterRBACEnabled {
+		err := fmt.Errorf("cluster RBAC scope requested but the operator is running without cluster RBAC permissions")
+		log.Error(err, "Cannot reconcile PacmanGame RBAC", "PacmanGame.Namespace", cr.Namespace, "PacmanGame.Name", cr.Name)
 		return ctrl.Result{}, err
-	} else {
-		// ServiceAccount already exists
-		log.Info("ServiceAccount already exists", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
 	}
-	// ServiceAccount reconcile finished
+	// Reconcile Pacman ClusterRole object
+	result, err := r.reconcilePacmanClusterRole(ctx, cr, log)
+	if err != nil {
+		return result, err
+	}
+	// Reconcile Pacman ClusterRoleBinding object
+	result, err = r.reconcilePacmanClusterRoleBinding(ctx, cr, log)
+	if err != nil {
+		return result, err
+	}
+	// Remove the namespaced RBAC objects left behind by a previous Namespace scope
+	if getManagementPolicy(cr) == appsv1beta1.ManagementPolicyManaged {
+		for _, obj := range []client.Object{newPacmanRoleBindingForCR(cr), newPacmanRoleForCR(cr)} {
+			if err := r.deleteIfExists(ctx, obj, log); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+	setCondition(cr, appsv1beta1.ConditionTypeRBACReady, metav1.ConditionTrue, appsv1beta1.ReasonRBACConfigured, "Pacman ServiceAccount can read the pods and nodes in the cluster")
+	// RBAC reconcile finished
+	return ctrl.Result{}, nil
+}
+
+func (r *PacmanGameReconciler) reconcilePacmanRole(ctx context.Context, cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+	// Define a new Role object
+	role := newPacmanRoleForCR(cr)
+
+	// Set PacmanGame instance as the owner and controller of the Role
+	if err := controllerutil.SetControllerReference(cr, role, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Apply the Role, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, role, log); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Role reconcile finished
+	return ctrl.Result{}, nil
+}
+
+func (r *PacmanGameReconciler) reconcilePacmanRoleBinding(ctx context.Context, cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+	// Define a new RoleBinding object
+	roleBinding := newPacmanRoleBindingForCR(cr)
+
+	// Set PacmanGame instance as the owner and controller of the RoleBinding
+	if err := controllerutil.SetControllerReference(cr, roleBinding, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Apply the RoleBinding, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, roleBinding, log); err != nil {
+		return ctrl.Result{}, err
+	}
+	// RoleBinding reconcile finished
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcilePacmanClusterRole(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcilePacmanClusterRole(ctx context.Context, cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
 	// Define a new ClusterRole object
 	clusterRole := newPacmanClusterRoleForCR(cr)
 
@@ -285,28 +583,15 @@ func (r *PacmanGameReconciler) reconcilePacmanClusterRole(cr *appsv1beta1.Pacman
 	//	return ctrl.Result{}, err
 	//}
 
-	// Check if this ClusterRole already exists
-	clusterRoleFound := &rbacv1.ClusterRole{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: clusterRole.Name}, clusterRoleFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new clusterRole", "clusterRole.Name", clusterRole.Name)
-		err = r.Create(context.Background(), clusterRole)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// ClusterRole created successfully - don't requeue
-		return ctrl.Result{}, nil
-	} else if err != nil {
+	// Apply the ClusterRole, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, clusterRole, log); err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// ClusterRole already exists
-		log.Info("clusterRole already exists", "clusterRole.Name", clusterRole.Name)
 	}
 	// ClusterRole reconcile finished
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcilePacmanClusterRoleBinding(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcilePacmanClusterRoleBinding(ctx context.Context, cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
 	// Define a new ClusterRoleBinding object
 	clusterRoleBinding := newPacmanClusterRoleBindingForCR(cr)
 
@@ -315,116 +600,54 @@ func (r *PacmanGameReconciler) reconcilePacmanClusterRoleBinding(cr *appsv1beta1
 	//	return ctrl.Result{}, err
 	//}
 
-	// Check if this ClusterRoleBinding already exists
-	clusterRoleBindingFound := &rbacv1.ClusterRoleBinding{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: clusterRoleBinding.Name}, clusterRoleBindingFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new clusterRole", "clusterRoleBinding.Name", clusterRoleBinding.Name)
-		err = r.Create(context.Background(), clusterRoleBinding)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// ClusterRoleBinding created successfully - don't requeue
-		return ctrl.Result{}, nil
-	} else if err != nil {
+	// Apply the ClusterRoleBinding, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, clusterRoleBinding, log); err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// ClusterRoleBinding already exists
-		log.Info("clusterRoleBinding already exists", "clusterRoleBinding.Name", clusterRoleBinding.Name)
 	}
 	// ClusterRoleBinding reconcile finished
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcilePacmanService(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcilePacmanService(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
 	// Define a new Service object
-	service := newPacmanServiceForCR(cr)
+	service := newPacmanServiceForCR(cr, defaults)
 
 	// Set PacmanGame instance as the owner and controller of the Service
 	if err := controllerutil.SetControllerReference(cr, service, r.Scheme); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Check if this Service already exists
-	serviceFound := &corev1.Service{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, serviceFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
-		err = r.Create(context.Background(), service)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// Service created successfully - don't requeue
-		return ctrl.Result{}, nil
-	} else if err != nil {
+	// Apply the Service, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, service, log); err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// Service already exists
-		log.Info("Service already exists", "Service.Namespace", serviceFound.Namespace, "Service.Name", serviceFound.Name)
 	}
+	// Update the exposure condition from the service state
+	setServiceCondition(cr, appsv1beta1.ConditionTypeExposureReady, service)
 	// Service reconcile finished
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcileMongoDeployment(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcileMongoDeployment(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
 	// Define a new Deployment object
-	deployment := newMongoDeploymentForCR(cr)
+	deployment := newMongoDeploymentForCR(cr, defaults)
 
 	// Set PacmanGame instance as the owner and controller of the Deployment
 	if err := ctrl.SetControllerReference(cr, deployment, r.Scheme); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Check if this Deployment already exists
-	deploymentFound := &appsv1.Deployment{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, deploymentFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
-		err = r.Create(context.Background(), deployment)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// Requeue the object to update its status
-		return ctrl.Result{Requeue: true}, nil
-	} else if err != nil {
+	// Apply the Deployment, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, deployment, log); err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// Deployment already exists
-		log.Info("Deployment already exists", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
 	}
 
-	// Ensure deployment replicas match the desired state
-	if !reflect.DeepEqual(deploymentFound.Spec.Replicas, deployment.Spec.Replicas) {
-		log.Info("Current deployment replicas do not match PacmanGame configured Replicas")
-		// Update the replicas
-		err = r.Update(context.Background(), deployment)
-		if err != nil {
-			log.Error(err, "Failed to update Deployment.", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
-			return ctrl.Result{}, err
-		}
-	}
-	// Ensure deployment container image match the desired state, returns true if deployment needs to be updated
-	if checkDeploymentImage(deploymentFound, deployment) {
-		log.Info("Current deployment image version do not match PacmanGame configured version")
-		// Update the image
-		err = r.Update(context.Background(), deployment)
-		if err != nil {
-			log.Error(err, "Failed to update Deployment.", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
-			return ctrl.Result{}, err
-		}
-	}
-
-	// Reconcile the new status for the instance
-	cr, err = r.updatePacmanGameStatus(cr, log)
-	if err != nil {
-		log.Error(err, "Failed to update PacmanGame Status.")
-		return ctrl.Result{}, err
-	}
+	// Update the database condition from the deployment readiness
+	setDeploymentCondition(cr, appsv1beta1.ConditionTypeDatabaseReady, deployment)
 	// Deployment reconcile finished
 	return ctrl.Result{}, nil
 }
 
-func (r *PacmanGameReconciler) reconcileMongoService(cr *appsv1beta1.PacmanGame, log logr.Logger) (ctrl.Result, error) {
+func (r *PacmanGameReconciler) reconcileMongoService(ctx context.Context, cr *appsv1beta1.PacmanGame, defaults Defaults, log logr.Logger) (ctrl.Result, error) {
 	// Define a new Service object
 	service := newMongoServiceForCR(cr)
 
@@ -433,88 +656,349 @@ func (r *PacmanGameReconciler) reconcileMongoService(cr *appsv1beta1.PacmanGame,
 		return ctrl.Result{}, err
 	}
 
-	// Check if this Service already exists
-	serviceFound := &corev1.Service{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, serviceFound)
-	if err != nil && errors.IsNotFound(err) {
-		log.Info("Creating a new Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
-		err = r.Create(context.Background(), service)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		// Service created successfully - don't requeue
-		return ctrl.Result{}, nil
-	} else if err != nil {
+	// Apply the Service, this corrects any drift on the fields managed by the operator
+	if _, err := r.applyObject(ctx, cr, service, log); err != nil {
 		return ctrl.Result{}, err
-	} else {
-		// Service already exists
-		log.Info("Service already exists", "Service.Namespace", serviceFound.Namespace, "Service.Name", serviceFound.Name)
 	}
 	// Service reconcile finished
 	return ctrl.Result{}, nil
 }
 
-// updatePacmanGameStatus updates the Status of a given CR
-func (r *PacmanGameReconciler) updatePacmanGameStatus(cr *appsv1beta1.PacmanGame, log logr.Logger) (*appsv1beta1.PacmanGame, error) {
-	pacmanGame := &appsv1beta1.PacmanGame{}
-	err := r.Get(context.Background(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, pacmanGame)
+// applyObject server-side applies a given object using the operator field manager. Ownership of the
+// fields set by the operator is forced so any drift on them is reverted, fields owned by other managers
+// and not set by the operator are left untouched. The object is updated with the live state.
+// In ObserveOnly the apply is only a dry run and the drift is recorded in the CR status instead
+func (r *PacmanGameReconciler) applyObject(ctx context.Context, cr *appsv1beta1.PacmanGame, obj client.Object, log logr.Logger) (controllerutil.OperationResult, error) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	// Get the current object to find out whether the apply creates or changes it
+	current, err := r.newObjectOfKind(obj)
 	if err != nil {
-		return pacmanGame, err
+		return controllerutil.OperationResultNone, err
+	}
+	err = r.Get(ctx, client.ObjectKeyFromObject(obj), current.(client.Object))
+	if err != nil && !errors.IsNotFound(err) {
+		return controllerutil.OperationResultNone, err
 	}
+	exists := err == nil
 
-	if !reflect.DeepEqual(cr.Status, pacmanGame.Status) {
-		log.Info("Updating PacmanGame Status.")
-		// We need to update the status
-		err = r.Status().Update(context.Background(), cr)
+	if getManagementPolicy(cr) == appsv1beta1.ManagementPolicyObserveOnly {
+		err = r.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership, client.DryRunAll)
 		if err != nil {
-			return cr, err
+			log.Error(err, "Failed to dry run apply "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+			return controllerutil.OperationResultNone, err
 		}
-		updatedPacmanGame := &appsv1beta1.PacmanGame{}
-		err = r.Get(context.Background(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, updatedPacmanGame)
+		drift, err := computeDrift(current.(client.Object), obj, exists)
 		if err != nil {
-			return cr, err
+			return controllerutil.OperationResultNone, err
+		}
+		if drift != nil {
+			log.Info("Found drift on "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName(), "Fields", drift.Fields)
+		}
+		recordDrift(cr, kind, obj.GetName(), drift)
+		return controllerutil.OperationResultNone, nil
+	}
+
+	// The apply overwrites the object with the applied one. The type of the typed objects read from the cache is
+	// not set, it's not compared
+	desired, err := toUnstructuredMap(obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	delete(desired, "apiVersion")
+	delete(desired, "kind")
+	err = r.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+	if err != nil {
+		log.Error(err, "Failed to apply "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+		return controllerutil.OperationResultNone, err
+	}
+	operation, err := applyOperation(current.(client.Object), desired, obj)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	switch operation {
+	case controllerutil.OperationResultCreated:
+		log.Info("Created a new "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+		r.recordEvent(cr, corev1.EventTypeNormal, EventReasonCreated, fmt.Sprintf("Created %s %s", kind, obj.GetName()))
+	case controllerutil.OperationResultUpdated:
+		log.Info("Corrected drift on "+kind, kind+".Namespace", obj.GetNamespace(), kind+".Name", obj.GetName())
+		if deployment, ok := obj.(*appsv1.Deployment); ok && exists {
+			r.recordDeploymentChanges(cr, current.(*appsv1.Deployment), deployment)
+		} else {
+			r.recordEvent(cr, corev1.EventTypeNormal, EventReasonUpdated, fmt.Sprintf("Updated %s %s", kind, obj.GetName()))
 		}
-		cr = updatedPacmanGame.DeepCopy()
 	}
-	return cr, nil
+	return operation, nil
+}
+
+// applyOperation tells whether the apply of a desired object created or changed it. An apply changes the object
+// when it sets a field to a new value or stops managing a field. Resource versions can't tell, the status writers
+// change them and the cache may not hold the latest one
+func applyOperation(previous client.Object, desired map[string]interface{}, applied client.Object) (controllerutil.OperationResult, error) {
+	before, after := appliedFieldsEntry(previous), appliedFieldsEntry(applied)
+	if after == nil {
+		return controllerutil.OperationResultNone, nil
+	}
+	// The apply creating an object is recorded at the object creation time
+	creationTimestamp := applied.GetCreationTimestamp()
+	if before == nil && after.Time != nil && after.Time.Equal(&creationTimestamp) {
+		return controllerutil.OperationResultCreated, nil
+	}
+	if before == nil || !equality.Semantic.DeepEqual(before.FieldsV1, after.FieldsV1) {
+		return controllerutil.OperationResultUpdated, nil
+	}
+	previousMap, err := toUnstructuredMap(previous)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if !containsFields(previousMap, desired) {
+		return controllerutil.OperationResultUpdated, nil
+	}
+	return controllerutil.OperationResultNone, nil
+}
+
+// containsFields returns true if an unstructured value holds every field set in a desired one with the same value.
+// Zero numbers and strings are left to the server defaults, like the target port of a Service
+func containsFields(value interface{}, desired interface{}) bool {
+	switch desired := desired.(type) {
+	case nil:
+		return true
+	case int64:
+		return desired == 0 || reflect.DeepEqual(value, desired)
+	case string:
+		return desired == "" || reflect.DeepEqual(value, desired)
+	case map[string]interface{}:
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return len(desired) == 0
+		}
+		for k, v := range desired {
+			if !containsFields(valueMap[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		valueList, ok := value.([]interface{})
+		if !ok || len(valueList) != len(desired) {
+			return false
+		}
+		for i := range desired {
+			if !containsFields(valueList[i], desired[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(value, desired)
+	}
+}
+
+// appliedFieldsEntry returns the managed fields entry of the operator applies on a given object, nil if it has none
+func appliedFieldsEntry(obj client.Object) *metav1.ManagedFieldsEntry {
+	managedFields := obj.GetManagedFields()
+	for i, entry := range managedFields {
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply && entry.Subresource == "" {
+			return &managedFields[i]
+		}
+	}
+	return nil
+}
+
+// newObjectOfKind returns an empty object of the same kind as a given object
+func (r *PacmanGameReconciler) newObjectOfKind(obj client.Object) (runtime.Object, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	// Kinds not registered in the scheme, like the monitoring ones, are handled as unstructured
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(gvk)
+		return current, nil
+	}
+	return r.Scheme.New(gvk)
+}
 
+// recordDeploymentChanges emits events for the image upgrades and scaling done on a deployment
+func (r *PacmanGameReconciler) recordDeploymentChanges(cr *appsv1beta1.PacmanGame, previous *appsv1.Deployment, current *appsv1.Deployment) {
+	changed := false
+	for _, prev := range previous.Spec.Template.Spec.Containers {
+		for _, curr := range current.Spec.Template.Spec.Containers {
+			// Only compare the images of containers with the same name
+			if prev.Name == curr.Name && prev.Image != curr.Image {
+				r.recordEvent(cr, corev1.EventTypeNormal, EventReasonImageUpgraded, fmt.Sprintf("Upgraded container %s of Deployment %s from %s to %s", curr.Name, current.Name, prev.Image, curr.Image))
+				if curr.Name == "pacman" {
+					recordUpgrade(cr)
+				}
+				changed = true
+			}
+		}
+	}
+	if getDesiredReplicas(previous) != getDesiredReplicas(current) {
+		r.recordEvent(cr, corev1.EventTypeNormal, EventReasonScaled, fmt.Sprintf("Scaled Deployment %s from %d to %d replicas", current.Name, getDesiredReplicas(previous), getDesiredReplicas(current)))
+		changed = true
+	}
+	if !changed && previous.Generation != current.Generation {
+		r.recordEvent(cr, corev1.EventTypeNormal, EventReasonUpdated, fmt.Sprintf("Updated Deployment %s", current.Name))
+	}
+}
+
+// recordEvent emits an event on a given CR, it's a no-op when the reconciler has no recorder
+func (r *PacmanGameReconciler) recordEvent(cr *appsv1beta1.PacmanGame, eventType string, reason string, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(cr, eventType, reason, message)
+}
+
+// isAutoscaled returns true if a HorizontalPodAutoscaler targets a given deployment
+func (r *PacmanGameReconciler) isAutoscaled(ctx context.Context, deployment *appsv1.Deployment) (bool, error) {
+	hpaList := &autoscalingv1.HorizontalPodAutoscalerList{}
+	err := r.List(ctx, hpaList, client.InNamespace(deployment.Namespace))
+	if err != nil {
+		return false, err
+	}
+	for _, hpa := range hpaList.Items {
+		target := hpa.Spec.ScaleTargetRef
+		if target.Kind == "Deployment" && target.Name == deployment.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isScaledExternally returns true if a manager other than the operator owns the replicas of a given deployment,
+// like kubectl scale or an autoscaler not using a HorizontalPodAutoscaler
+func isScaledExternally(deployment *appsv1.Deployment) (bool, error) {
+	for _, entry := range deployment.ManagedFields {
+		if entry.Manager == FieldManager || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return false, err
+		}
+		if spec, ok := fields["f:spec"].(map[string]interface{}); ok {
+			if _, ok := spec["f:replicas"]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// patchPacmanGameStatus writes the status computed during the reconcile with a single merge patch.
+// The write is skipped when the status didn't change, conflicts are retried against the latest CR
+func (r *PacmanGameReconciler) patchPacmanGameStatus(ctx context.Context, cr *appsv1beta1.PacmanGame, originalStatus *appsv1beta1.PacmanGameStatus, log logr.Logger) error {
+	if equality.Semantic.DeepEqual(*originalStatus, cr.Status) {
+		return nil
+	}
+	log.Info("Updating PacmanGame Status.")
+	status := cr.Status.DeepCopy()
+	if status.AppPods == nil {
+		// The CRD requires appPods, it's empty until the Pacman pods have been listed
+		status.AppPods = []string{}
+	}
+	// Only the status differs from the patch base, so no other field is sent. The patch carries no
+	// resourceVersion, the status is owned by the operator and edits to the spec or metadata can't conflict with it
+	base := cr.DeepCopy()
+	base.Status = *originalStatus
+	patched := base.DeepCopy()
+	patched.Status = *status
+	if err := r.Status().Patch(ctx, patched, client.MergeFrom(base)); err != nil {
+		log.Error(err, "Failed to update PacmanGame Status.")
+		return err
+	}
+	patched.DeepCopyInto(cr)
+	return nil
+}
+
+// reportFailure marks the component that failed to reconcile in the CR status and returns the error,
+// the status is written at the end of the reconcile along with the state of the other components
+func (r *PacmanGameReconciler) reportFailure(cr *appsv1beta1.PacmanGame, conditionType string, step string, err error, log logr.Logger) error {
+	recordReconcileFailure(cr, step)
+	// Only emit an event when the failure changes, retries of the same failure are not reported again
+	previous := meta.FindStatusCondition(cr.Status.Conditions, conditionType)
+	if previous == nil || previous.Reason != appsv1beta1.ReasonReconcileFailed || previous.Message != err.Error() {
+		r.recordEvent(cr, corev1.EventTypeWarning, EventReasonReconcileFailed, fmt.Sprintf("Failed to reconcile %s: %s", conditionType, err.Error()))
+	}
+	setComponentFailed(cr, conditionType, err)
+	log.Error(err, "Failed to reconcile PacmanGame component", "Step", step, "Component", conditionType)
+	return err
+}
+
+// setDriftStatus reports the drift found on the managed objects in ObserveOnly,
+// the drift is cleared for any other management policy
+func (r *PacmanGameReconciler) setDriftStatus(cr *appsv1beta1.PacmanGame) {
+	if getManagementPolicy(cr) != appsv1beta1.ManagementPolicyObserveOnly {
+		cr.Status.Drift = nil
+		meta.RemoveStatusCondition(&cr.Status.Conditions, appsv1beta1.ConditionTypeDrifted)
+	} else if len(cr.Status.Drift) > 0 {
+		if !meta.IsStatusConditionTrue(cr.Status.Conditions, appsv1beta1.ConditionTypeDrifted) {
+			r.recordEvent(cr, corev1.EventTypeWarning, EventReasonDriftDetected, fmt.Sprintf("%d managed objects differ from the desired state", len(cr.Status.Drift)))
+		}
+		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeDrifted, Status: metav1.ConditionTrue, Reason: "DriftDetected", Message: fmt.Sprintf("%d managed objects differ from the desired state", len(cr.Status.Drift))})
+	} else {
+		meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{Type: appsv1beta1.ConditionTypeDrifted, Status: metav1.ConditionFalse, Reason: "NoDrift", Message: "All managed objects match the desired state"})
+	}
 }
 
 // addFinalizer adds a given finalizer to a given CR
-func (r *PacmanGameReconciler) addFinalizer(log logr.Logger, cr *appsv1beta1.PacmanGame) error {
+func (r *PacmanGameReconciler) addFinalizer(ctx context.Context, log logr.Logger, cr *appsv1beta1.PacmanGame) error {
 	log.Info("Adding Finalizer for the PacmanGame")
 	controllerutil.AddFinalizer(cr, PacmanGameFinalizer)
 
 	// Update CR
-	err := r.Update(context.Background(), cr)
+	err := r.Update(ctx, cr)
 	if err != nil {
 		log.Error(err, "Failed to update PacmanGame with finalizer")
 		return err
 	}
+	r.recordEvent(cr, corev1.EventTypeNormal, EventReasonFinalizerAdded, "Added finalizer "+PacmanGameFinalizer)
+	return nil
+}
+
+// deletePacmanClusterRBAC deletes the ClusterRoleBinding and ClusterRole created for a given CR
+func (r *PacmanGameReconciler) deletePacmanClusterRBAC(ctx context.Context, cr *appsv1beta1.PacmanGame, log logr.Logger) error {
+	for _, obj := range []client.Object{newPacmanClusterRoleBindingForCR(cr), newPacmanClusterRoleForCR(cr)} {
+		if err := r.deleteIfExists(ctx, obj, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteIfExists deletes a given object, ignoring it if it's already gone
+func (r *PacmanGameReconciler) deleteIfExists(ctx context.Context, obj client.Object, log logr.Logger) error {
+	err := r.Delete(ctx, obj)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		log.Error(err, "Failed to delete object", "Object.Namespace", obj.GetNamespace(), "Object.Name", obj.GetName())
+		return err
+	}
+	log.Info("Deleted object", "Object.Namespace", obj.GetNamespace(), "Object.Name", obj.GetName())
 	return nil
 }
 
 // finalizePacmanGame runs required tasks before deleting the objects owned by the CR
-func (r *PacmanGameReconciler) finalizePacmanGame(log logr.Logger, cr *appsv1beta1.PacmanGame) error {
-	// TODO(user): Add the cleanup steps that the operator
-	// needs to do before the CR can be deleted. Examples
-	// of finalizers include performing backups and deleting
-	// resources that are not owned by this CR, like a PVC.
+func (r *PacmanGameReconciler) finalizePacmanGame(ctx context.Context, log logr.Logger, cr *appsv1beta1.PacmanGame) error {
+	// Cluster scoped RBAC objects can't be owned by the CR, so they are not garbage collected
+	if r.ClusterRBACEnabled && getManagementPolicy(cr) == appsv1beta1.ManagementPolicyManaged {
+		if err := r.deletePacmanClusterRBAC(ctx, cr, log); err != nil {
+			return err
+		}
+	}
 	log.Info("Successfully finalized PacmanGame")
 	return nil
 }
 
 // Returns a new deployment without replicas configured
 // replicas will be configured in the sync loop
-func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame, defaults Defaults) *appsv1.Deployment {
+	labels := labelsForCR(cr, "mongo")
 	// Replicas will be 1
 	var replicas int32 = 1
 
-	containerImage := "docker.io/library/mongo:latest"
-	return &appsv1.Deployment{
+	containerImage := defaults.MongoImage
+	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
 			Kind:       "Deployment",
@@ -527,13 +1011,14 @@ func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
+				MatchLabels: selectorLabelsForCR(cr),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
+					ImagePullSecrets: cr.Spec.ImagePullSecrets,
 					Volumes: []corev1.Volume{
 						{
 							Name: "mongodb-storage",
@@ -546,8 +1031,9 @@ func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 					},
 					Containers: []corev1.Container{
 						{
-							Image: containerImage,
-							Name:  "mongo",
+							Image:     containerImage,
+							Name:      "mongo",
+							Resources: defaults.MongoResources,
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "mongodb-storage",
@@ -557,17 +1043,18 @@ func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 							Env: []corev1.EnvVar{
 								{
 									Name:  "MONGO_INITDB_ROOT_USERNAME",
-									Value: "admin",
+									Value: mongoUser,
 								},
 								{
 									Name:  "MONGO_INITDB_ROOT_PASSWORD",
-									Value: "admin",
+									Value: mongoPassword,
 								},
 							},
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: 27017,
 									Name:          "mongo",
+									Protocol:      corev1.ProtocolTCP,
 								},
 							},
 						},
@@ -576,14 +1063,54 @@ func newMongoDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 			},
 		},
 	}
+	// Add the metrics exporter sidecar, it connects to mongo through the pod network namespace
+	if cr.Spec.Mongo.Exporter.Enabled {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, newMongoExporterContainerForCR(cr, defaults))
+	}
+	return deployment
+}
+
+// Returns a new mongo metrics exporter container
+func newMongoExporterContainerForCR(cr *appsv1beta1.PacmanGame, defaults Defaults) corev1.Container {
+	containerImage := defaults.MongoExporterImage
+	if cr.Spec.Mongo.Exporter.Image != "" {
+		containerImage = cr.Spec.Mongo.Exporter.Image
+	}
+	return corev1.Container{
+		Image: containerImage,
+		Name:  "mongo-exporter",
+		Args: []string{
+			"--compatible-mode",
+			"--web.listen-address=:" + strconv.Itoa(mongoExporterPort),
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "MONGODB_URI",
+				Value: "mongodb://localhost:27017",
+			},
+			{
+				Name:  "MONGODB_USER",
+				Value: mongoUser,
+			},
+			{
+				Name:  "MONGODB_PASSWORD",
+				Value: mongoPassword,
+			},
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				ContainerPort: mongoExporterPort,
+				Name:          "metrics",
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+	}
 }
 
 // Returns a new mongo service
 func newMongoServiceForCR(cr *appsv1beta1.PacmanGame) *corev1.Service {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
-	return &corev1.Service{
+	labels := labelsForCR(cr, "mongo")
+	service := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Service",
@@ -595,35 +1122,41 @@ func newMongoServiceForCR(cr *appsv1beta1.PacmanGame) *corev1.Service {
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     corev1.ServiceTypeClusterIP,
-			Selector: labels,
+			Selector: serviceSelectorForCR(cr, "mongo"),
 			Ports: []corev1.ServicePort{
 				{
-					Name: "http",
-					Port: 27017,
+					Name:     "http",
+					Port:     27017,
+					Protocol: corev1.ProtocolTCP,
 				},
 			},
 		},
 	}
+	// Expose the metrics exporter so any scraping setup can find it through the port name
+	if cr.Spec.Mongo.Exporter.Enabled {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:     "metrics",
+			Port:     mongoExporterPort,
+			Protocol: corev1.ProtocolTCP,
+		})
+	}
+	return service
 }
 
-// Returns a new deployment without replicas configured
+// Returns a new deployment without replicas configured running a given Pacman image
 // replicas will be configured in the sync loop
-func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame, defaults Defaults, containerImage string) *appsv1.Deployment {
+	labels := labelsForCR(cr, "pacman")
 	replicas := cr.Spec.Replicas
-	// Minimum replicas will be 1
 	if replicas == 0 {
-		replicas = 1
-	}
-	appVersion := "latest"
-	if cr.Spec.AppVersion != "" {
-		appVersion = cr.Spec.AppVersion
+		replicas = defaults.PacmanReplicas
 	}
 	mongoService := "mongo-" + cr.Name + "." + cr.Namespace + ".svc.cluster.local"
-	// TODO:Check if application version exists
-	containerImage := "quay.io/ifont/pacman-nodejs-app:" + appVersion
+	// Node information can only be read when the game runs with cluster permissions
+	nodeInfoEnabled := "true"
+	if getRBACScope(cr) == appsv1beta1.RBACScopeNamespace {
+		nodeInfoEnabled = "false"
+	}
 	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
@@ -637,7 +1170,7 @@ func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
+				MatchLabels: selectorLabelsForCR(cr),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
@@ -645,10 +1178,13 @@ func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: "pacman-" + cr.Name,
+					ImagePullSecrets:   cr.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
-							Image: containerImage,
-							Name:  "pacman",
+							Image:           containerImage,
+							ImagePullPolicy: cr.Spec.Pacman.Image.PullPolicy,
+							Name:            "pacman",
+							Resources:       defaults.PacmanResources,
 							Env: []corev1.EnvVar{
 								{
 									Name:  "MONGO_SERVICE_HOST",
@@ -656,11 +1192,11 @@ func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 								},
 								{
 									Name:  "MONGO_AUTH_USER",
-									Value: "admin",
+									Value: mongoUser,
 								},
 								{
 									Name:  "MONGO_AUTH_PWD",
-									Value: "admin",
+									Value: mongoPassword,
 								},
 								{
 									Name:  "MONGO_DATABASE",
@@ -674,11 +1210,16 @@ func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 									Name:      "MY_NODE_NAME",
 									ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}},
 								},
+								{
+									Name:  "NODE_INFO_ENABLED",
+									Value: nodeInfoEnabled,
+								},
 							},
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: 8080,
 									Name:          "pacman",
+									Protocol:      corev1.ProtocolTCP,
 								},
 							},
 						},
@@ -690,10 +1231,8 @@ func newPacmanDeploymentForCR(cr *appsv1beta1.PacmanGame) *appsv1.Deployment {
 }
 
 // Returns a new pacman service
-func newPacmanServiceForCR(cr *appsv1beta1.PacmanGame) *corev1.Service {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+func newPacmanServiceForCR(cr *appsv1beta1.PacmanGame, defaults Defaults) *corev1.Service {
+	labels := labelsForCR(cr, "pacman")
 	return &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -705,12 +1244,13 @@ func newPacmanServiceForCR(cr *appsv1beta1.PacmanGame) *corev1.Service {
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
-			Type:     corev1.ServiceTypeLoadBalancer,
-			Selector: labels,
+			Type:     defaults.PacmanServiceType,
+			Selector: serviceSelectorForCR(cr, "pacman"),
 			Ports: []corev1.ServicePort{
 				{
-					Name: "http",
-					Port: 8080,
+					Name:     "http",
+					Port:     8080,
+					Protocol: corev1.ProtocolTCP,
 				},
 			},
 		},
@@ -719,15 +1259,18 @@ func newPacmanServiceForCR(cr *appsv1beta1.PacmanGame) *corev1.Service {
 
 // Returns a new serviceaccount
 func newPacmanServiceAccountForCR(cr *appsv1beta1.PacmanGame) *corev1.ServiceAccount {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+	labels := labelsForCR(cr, "pacman")
 	return &corev1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ServiceAccount",
+		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "pacman-" + cr.Name,
 			Namespace: cr.Namespace,
 			Labels:    labels,
 		},
+		ImagePullSecrets: cr.Spec.ImagePullSecrets,
 	}
 }
 
@@ -749,13 +1292,16 @@ func newPacmanClusterRoleForCR(cr *appsv1beta1.PacmanGame) *rbacv1.ClusterRole {
 			},
 		},
 	}
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+	labels := labelsForCR(cr, "pacman")
 	return &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRole",
+		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   "pacman-" + cr.Name,
-			Labels: labels,
+			Name:        "pacman-" + cr.Name,
+			Labels:      labels,
+			Annotations: ownerAnnotationsForCR(cr),
 		},
 		Rules: rules,
 	}
@@ -764,13 +1310,16 @@ func newPacmanClusterRoleForCR(cr *appsv1beta1.PacmanGame) *rbacv1.ClusterRole {
 
 // Returns a clusterRole
 func newPacmanClusterRoleBindingForCR(cr *appsv1beta1.PacmanGame) *rbacv1.ClusterRoleBinding {
-	labels := map[string]string{
-		"app": cr.Name,
-	}
+	labels := labelsForCR(cr, "pacman")
 	return &rbacv1.ClusterRoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "ClusterRoleBinding",
+		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   "pacman-" + cr.Name,
-			Labels: labels,
+			Name:        "pacman-" + cr.Name,
+			Labels:      labels,
+			Annotations: ownerAnnotationsForCR(cr),
 		},
 		Subjects: []rbacv1.Subject{
 			{
@@ -787,15 +1336,141 @@ func newPacmanClusterRoleBindingForCR(cr *appsv1beta1.PacmanGame) *rbacv1.Cluste
 	}
 }
 
-// isDeploymentReady returns a true bool if the deployment has all its pods ready
+// Returns a role
+func newPacmanRoleForCR(cr *appsv1beta1.PacmanGame) *rbacv1.Role {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{
+				"",
+			},
+			Resources: []string{
+				"pods",
+			},
+			Verbs: []string{
+				"get",
+				"watch",
+				"list",
+			},
+		},
+	}
+	labels := labelsForCR(cr, "pacman")
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "Role",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pacman-" + cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Rules: rules,
+	}
+}
+
+// Returns a roleBinding
+func newPacmanRoleBindingForCR(cr *appsv1beta1.PacmanGame) *rbacv1.RoleBinding {
+	labels := labelsForCR(cr, "pacman")
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pacman-" + cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      "pacman-" + cr.Name,
+				Namespace: cr.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     "pacman-" + cr.Name,
+		},
+	}
+}
+
+// labelsForCR returns the labels for the objects of a given component of the CR
+func labelsForCR(cr *appsv1beta1.PacmanGame, component string) map[string]string {
+	return map[string]string{
+		"app":           cr.Name,
+		ManagedByLabel:  FieldManager,
+		ComponentLabel:  component,
+		PacmanGameLabel: cr.Name,
+	}
+}
+
+// ownerAnnotationsForCR returns the annotations that map a cluster scoped object back to the CR
+func ownerAnnotationsForCR(cr *appsv1beta1.PacmanGame) map[string]string {
+	return map[string]string{
+		PacmanGameOwnerAnnotation: cr.Namespace + "/" + cr.Name,
+	}
+}
+
+// selectorLabelsForCR returns the labels used to select the pods of the CR
+// Deployment selectors are immutable, so they only use the app label the operator always set
+func selectorLabelsForCR(cr *appsv1beta1.PacmanGame) map[string]string {
+	return map[string]string{
+		"app": cr.Name,
+	}
+}
+
+// serviceSelectorForCR returns the labels used by the services to select the pods of a given component
+func serviceSelectorForCR(cr *appsv1beta1.PacmanGame, component string) map[string]string {
+	return map[string]string{
+		"app":          cr.Name,
+		ComponentLabel: component,
+	}
+}
+
+// getAppVersion returns the Pacman version configured for a given CR, defaulting to latest
+func getAppVersion(cr *appsv1beta1.PacmanGame) string {
+	if cr.Spec.AppVersion == "" {
+		return "latest"
+	}
+	return cr.Spec.AppVersion
+}
+
+// getRBACScope returns the RBAC scope configured for a given CR, defaulting to Cluster
+func getRBACScope(cr *appsv1beta1.PacmanGame) appsv1beta1.RBACScope {
+	if cr.Spec.RBAC.Scope == "" {
+		return appsv1beta1.RBACScopeCluster
+	}
+	return cr.Spec.RBAC.Scope
+}
+
+// getManagementPolicy returns the management policy configured for a given CR, defaulting to Managed
+func getManagementPolicy(cr *appsv1beta1.PacmanGame) appsv1beta1.ManagementPolicy {
+	if cr.Spec.ManagementPolicy == "" {
+		return appsv1beta1.ManagementPolicyManaged
+	}
+	return cr.Spec.ManagementPolicy
+}
+
+// isDeploymentReady returns a true bool if the deployment has rolled out all its desired pods and they are ready
 func isDeploymentReady(deployment *appsv1.Deployment) bool {
-	configuredReplicas := deployment.Status.Replicas
-	readyReplicas := deployment.Status.ReadyReplicas
-	deploymentReady := false
-	if configuredReplicas == readyReplicas {
-		deploymentReady = true
+	// The deployment controller has not processed the latest spec yet
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
 	}
-	return deploymentReady
+	desiredReplicas := getDesiredReplicas(deployment)
+	return deployment.Status.UpdatedReplicas == desiredReplicas &&
+		deployment.Status.ReadyReplicas == desiredReplicas &&
+		deployment.Status.Replicas == desiredReplicas
+}
+
+// getDesiredReplicas returns the number of replicas requested in the deployment spec, defaulting to 1
+func getDesiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
 }
 
 // getRunningPodNames returns the pod names for the pods running in the array of pods passed in
@@ -810,22 +1485,36 @@ func getRunningPodNames(pods []corev1.Pod) []string {
 			podNames = append(podNames, pod.Name)
 		}
 	}
+	// Sorted so the status only changes when the pods do
+	sort.Strings(podNames)
 	return podNames
 }
 
-// checkDeploymentImage returns wether the deployment image is different or not
-func checkDeploymentImage(current *appsv1.Deployment, desired *appsv1.Deployment) bool {
-	for _, curr := range current.Spec.Template.Spec.Containers {
-		for _, des := range desired.Spec.Template.Spec.Containers {
-			// Only compare the images of containers with the same name
-			if curr.Name == des.Name {
-				if curr.Image != des.Image {
-					return true
-				}
+// getPodStatuses returns the state of the pods passed in, skipping the pods being deleted
+func getPodStatuses(pods []corev1.Pod) []appsv1beta1.PacmanGamePod {
+	podStatuses := make([]appsv1beta1.PacmanGamePod, 0, len(pods))
+	for _, pod := range pods {
+		if pod.GetObjectMeta().GetDeletionTimestamp() != nil {
+			continue
+		}
+		podStatus := appsv1beta1.PacmanGamePod{
+			Name:     pod.Name,
+			Phase:    pod.Status.Phase,
+			NodeName: pod.Spec.NodeName,
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady {
+				podStatus.Ready = condition.Status == corev1.ConditionTrue
 			}
 		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			podStatus.Restarts += containerStatus.RestartCount
+		}
+		podStatuses = append(podStatuses, podStatus)
 	}
-	return false
+	// Keep a stable order so the status is not rewritten when the pods are listed in a different order
+	sort.Slice(podStatuses, func(i, j int) bool { return podStatuses[i].Name < podStatuses[j].Name })
+	return podStatuses
 }
 
 // contains returns true if a string is found on a slice
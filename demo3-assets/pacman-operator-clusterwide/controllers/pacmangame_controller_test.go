@@ -0,0 +1,140 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// deploymentManagedBy returns a deployment whose fields are managed by the given entries
+func deploymentManagedBy(created time.Time, entries ...metav1.ManagedFieldsEntry) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{}
+	deployment.CreationTimestamp = metav1.NewTime(created)
+	deployment.ManagedFields = entries
+	return deployment
+}
+
+// managedFieldsEntry returns a managed fields entry of a given manager recorded at a given time
+func managedFieldsEntry(manager string, operation metav1.ManagedFieldsOperationType, at time.Time, fields string) metav1.ManagedFieldsEntry {
+	time := metav1.NewTime(at)
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  operation,
+		Time:       &time,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+// deploymentWithReplicas returns a deployment running a given number of replicas whose fields are managed by the
+// given entries
+func deploymentWithReplicas(replicas int32, created time.Time, entries ...metav1.ManagedFieldsEntry) *appsv1.Deployment {
+	deployment := deploymentManagedBy(created, entries...)
+	deployment.Spec.Replicas = &replicas
+	return deployment
+}
+
+func TestApplyOperation(t *testing.T) {
+	created := time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC)
+	later := created.Add(time.Hour)
+	applied := managedFieldsEntry(FieldManager, metav1.ManagedFieldsOperationApply, created, `{"f:spec":{"f:replicas":{}}}`)
+	reapplied := managedFieldsEntry(FieldManager, metav1.ManagedFieldsOperationApply, later, `{"f:spec":{"f:replicas":{}}}`)
+	templateApplied := managedFieldsEntry(FieldManager, metav1.ManagedFieldsOperationApply, later, `{"f:spec":{"f:template":{}}}`)
+	statusWriter := managedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, later, `{"f:status":{}}`)
+	oneReplica := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}}
+	twoReplicas := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(2)}}
+
+	tests := []struct {
+		name     string
+		previous client.Object
+		desired  map[string]interface{}
+		applied  client.Object
+		want     controllerutil.OperationResult
+	}{
+		{"created", &appsv1.Deployment{}, oneReplica, deploymentWithReplicas(1, created, applied), controllerutil.OperationResultCreated},
+		{"missing from the cache", &appsv1.Deployment{}, oneReplica, deploymentWithReplicas(1, created, reapplied), controllerutil.OperationResultUpdated},
+		{"unchanged", deploymentWithReplicas(1, created, applied), oneReplica, deploymentWithReplicas(1, created, applied), controllerutil.OperationResultNone},
+		{"status written by another manager", deploymentWithReplicas(1, created, applied), oneReplica, deploymentWithReplicas(1, created, applied, statusWriter), controllerutil.OperationResultNone},
+		// The server records the time of the managed fields in seconds, changes made within the same second are
+		// found from the values
+		{"value changed", deploymentWithReplicas(1, created, applied), twoReplicas, deploymentWithReplicas(2, created, applied), controllerutil.OperationResultUpdated},
+		{"field no longer managed", deploymentWithReplicas(1, created, applied), map[string]interface{}{}, deploymentWithReplicas(1, created, templateApplied), controllerutil.OperationResultUpdated},
+		{"adopted", deploymentWithReplicas(1, created, statusWriter), oneReplica, deploymentWithReplicas(1, created, statusWriter, reapplied), controllerutil.OperationResultUpdated},
+	}
+	for _, test := range tests {
+		got, err := applyOperation(test.previous, test.desired, test.applied)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
+
+func TestIsScaledExternally(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		want       bool
+	}{
+		{"not found", &appsv1.Deployment{}, false},
+		{"scaled by the operator", deploymentManagedBy(now, managedFieldsEntry(FieldManager, metav1.ManagedFieldsOperationApply, now, `{"f:spec":{"f:replicas":{},"f:template":{}}}`)), false},
+		{"status written by another manager", deploymentManagedBy(now, managedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, now, `{"f:status":{"f:replicas":{}}}`)), false},
+		{"scaled with kubectl", deploymentManagedBy(now, managedFieldsEntry("kubectl", metav1.ManagedFieldsOperationUpdate, now, `{"f:spec":{"f:replicas":{}}}`)), true},
+	}
+	for _, test := range tests {
+		got, err := isScaledExternally(test.deployment)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestContainsFields(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterIP": "10.0.0.1",
+			"ports":     []interface{}{map[string]interface{}{"port": int64(8080), "targetPort": int64(8080), "protocol": "TCP"}},
+		},
+	}
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		want    bool
+	}{
+		{"fields defaulted by the server", map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(8080), "targetPort": int64(0)}}}}, true},
+		{"value changed", map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80)}}}}, false},
+		{"item added", map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{map[string]interface{}{}, map[string]interface{}{}}}}, false},
+		{"field added", map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "game"}}}, false},
+	}
+	for _, test := range tests {
+		if got := containsFields(live, test.desired); got != test.want {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPatchPacmanGameStatusKeepsConcurrentEdits(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(testScheme))
+	game := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"}}
+	apiClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(game).Build()
+	counter := &statusWriteCounter{Client: apiClient}
+	r := &PacmanGameReconciler{Client: counter, Scheme: testScheme}
+
+	ctx := context.Background()
+	cr := &appsv1beta1.PacmanGame{}
+	if err := apiClient.Get(ctx, client.ObjectKeyFromObject(game), cr); err != nil {
+		t.Fatal(err)
+	}
+	originalStatus := cr.Status.DeepCopy()
+	// The CR is edited by someone else while it's reconciled
+	edited := cr.DeepCopy()
+	edited.Labels = map[string]string{"edited": "true"}
+	if err := apiClient.Update(ctx, edited); err != nil {
+		t.Fatal(err)
+	}
+
+	setCondition(cr, appsv1beta1.ConditionTypeReady, metav1.ConditionTrue, "Reconciled", "Game is ready")
+	if err := r.patchPacmanGameStatus(ctx, cr, originalStatus, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	if writes, conflicts := atomic.LoadInt64(&counter.writes), atomic.LoadInt64(&counter.conflicts); writes != 1 || conflicts != 0 {
+		t.Errorf("expected 1 status write and no conflicts, got %d writes and %d conflicts", writes, conflicts)
+	}
+	latest := &appsv1beta1.PacmanGame{}
+	if err := apiClient.Get(ctx, client.ObjectKeyFromObject(game), latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.Labels["edited"] != "true" || len(latest.Status.Conditions) != 1 {
+		t.Errorf("expected the edit and the status to be kept, got labels %v and conditions %v", latest.Labels, latest.Status.Conditions)
+	}
+}
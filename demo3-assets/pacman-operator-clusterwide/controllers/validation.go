@@ -0,0 +1,37 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validatePacmanGame returns an error listing the invalid values of the CR spec the CRD schema can't reject
+func validatePacmanGame(cr *appsv1beta1.PacmanGame) error {
+	var errs field.ErrorList
+	imagePath := field.NewPath("spec", "pacman", "image")
+	if image := cr.Spec.Pacman.Image; image.Tag != "" && image.Digest != "" {
+		errs = append(errs, field.Forbidden(imagePath.Child("digest"), "tag and digest can't be both set"))
+	}
+	for i, secret := range cr.Spec.ImagePullSecrets {
+		if secret.Name == "" {
+			errs = append(errs, field.Required(field.NewPath("spec", "imagePullSecrets").Index(i).Child("name"), ""))
+		}
+	}
+	return errs.ToAggregate()
+}
@@ -0,0 +1,168 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidatePacmanGame(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   appsv1beta1.PacmanGameSpec
+		errors []string
+	}{
+		{name: "valid spec", spec: appsv1beta1.PacmanGameSpec{
+			Pacman:           appsv1beta1.PacmanGamePacman{Image: appsv1beta1.PacmanImage{Repository: "quay.io/ifont/pacman-nodejs-app", Tag: "v0.0.2"}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+		}},
+		{name: "empty spec"},
+		{name: "digest only", spec: appsv1beta1.PacmanGameSpec{
+			Pacman: appsv1beta1.PacmanGamePacman{Image: appsv1beta1.PacmanImage{Digest: "sha256:0123"}},
+		}},
+		{name: "tag and digest", spec: appsv1beta1.PacmanGameSpec{
+			Pacman: appsv1beta1.PacmanGamePacman{Image: appsv1beta1.PacmanImage{Tag: "v0.0.2", Digest: "sha256:0123"}},
+		}, errors: []string{"spec.pacman.image.digest"}},
+		{name: "empty pull secret name", spec: appsv1beta1.PacmanGameSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}, {Name: ""}},
+		}, errors: []string{"spec.imagePullSecrets[1].name"}},
+		{name: "every invalid value", spec: appsv1beta1.PacmanGameSpec{
+			Pacman:           appsv1beta1.PacmanGamePacman{Image: appsv1beta1.PacmanImage{Tag: "v0.0.2", Digest: "sha256:0123"}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: ""}},
+		}, errors: []string{"spec.pacman.image.digest", "spec.imagePullSecrets[0].name"}},
+	}
+	for _, test := range tests {
+		err := validatePacmanGame(&appsv1beta1.PacmanGame{Spec: test.spec})
+		if len(test.errors) == 0 {
+			if err != nil {
+				t.Errorf("%s: expected a valid spec, got %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected errors for %v, got none", test.name, test.errors)
+			continue
+		}
+		for _, path := range test.errors {
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("%s: expected an error for %s, got %v", test.name, path, err)
+			}
+		}
+	}
+}
+
+func TestSetInvalidSpecConditions(t *testing.T) {
+	cr := &appsv1beta1.PacmanGame{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	if isSpecInvalid(cr) {
+		t.Errorf("expected a game without conditions not to be reported as invalid")
+	}
+
+	setInvalidSpecConditions(cr, validatePacmanGame(&appsv1beta1.PacmanGame{Spec: appsv1beta1.PacmanGameSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: ""}},
+	}}))
+	expected := map[string]metav1.ConditionStatus{
+		appsv1beta1.ConditionTypeReady:       metav1.ConditionFalse,
+		appsv1beta1.ConditionTypeProgressing: metav1.ConditionFalse,
+		appsv1beta1.ConditionTypeDegraded:    metav1.ConditionTrue,
+	}
+	for conditionType, status := range expected {
+		condition := meta.FindStatusCondition(cr.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != status || condition.Reason != appsv1beta1.ReasonInvalidSpec {
+			t.Errorf("expected %s to be %s with reason %s, got %v", conditionType, status, appsv1beta1.ReasonInvalidSpec, condition)
+			continue
+		}
+		if !strings.HasPrefix(condition.Message, "Invalid spec: ") {
+			t.Errorf("expected the %s message to report the invalid spec, got %q", conditionType, condition.Message)
+		}
+	}
+	if cr.Status.ObservedGeneration != 2 || !isSpecInvalid(cr) {
+		t.Errorf("expected generation 2 to be reported as invalid, got observed generation %d", cr.Status.ObservedGeneration)
+	}
+
+	// A new generation hasn't been validated yet
+	cr.Generation = 3
+	if isSpecInvalid(cr) {
+		t.Errorf("expected generation 3 not to be reported as invalid yet")
+	}
+	// Other Degraded reasons aren't an invalid spec
+	cr.Generation = 2
+	setCondition(cr, appsv1beta1.ConditionTypeDegraded, metav1.ConditionTrue, appsv1beta1.ReasonReconcileFailed, "Failed")
+	if isSpecInvalid(cr) {
+		t.Errorf("expected a game degraded by a failed reconcile not to be reported as invalid")
+	}
+}
+
+func TestReconcileSkipsInvalidSpec(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(appsv1beta1.AddToScheme(testScheme))
+	game := &appsv1beta1.PacmanGame{
+		ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "pacman"},
+		Spec: appsv1beta1.PacmanGameSpec{
+			Pacman: appsv1beta1.PacmanGamePacman{Image: appsv1beta1.PacmanImage{Tag: "v0.0.2", Digest: "sha256:0123"}},
+		},
+	}
+	apiClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(game).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &PacmanGameReconciler{Client: apiClient, Cache: apiClient, Scheme: testScheme, Recorder: recorder}
+
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(game)}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile %d failed: %v", i, err)
+		}
+	}
+
+	// The game objects aren't rendered
+	deployments := &appsv1.DeploymentList{}
+	if err := apiClient.List(ctx, deployments, client.InNamespace(game.Namespace)); err != nil || len(deployments.Items) != 0 {
+		t.Errorf("expected no Deployment to be created, got %d: %v", len(deployments.Items), err)
+	}
+	latest := &appsv1beta1.PacmanGame{}
+	if err := apiClient.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if !isSpecInvalid(latest) {
+		t.Errorf("expected the invalid spec to be reported, got conditions %v", latest.Status.Conditions)
+	}
+	// The invalid spec is only reported once per generation
+	invalidSpecEvents := 0
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, EventReasonInvalidSpec) {
+			invalidSpecEvents++
+		}
+	}
+	if invalidSpecEvents != 1 {
+		t.Errorf("expected 1 %s event, got %d", EventReasonInvalidSpec, invalidSpecEvents)
+	}
+}
@@ -3,11 +3,30 @@ module github.com/mvazquezc/pacman-operator
 go 1.16
 
 require (
+	github.com/docker/cli v20.10.10+incompatible
 	github.com/go-logr/logr v0.4.0
+	github.com/google/go-containerregistry v0.7.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.15.0
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.30.0
+	github.com/sigstore/cosign v1.2.1
+	github.com/sigstore/sigstore v0.0.0-20210729211320-56a91f560f44
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.25.0
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
+	go.opentelemetry.io/proto/otlp v0.9.0
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
+	google.golang.org/protobuf v1.27.1
 	k8s.io/api v0.22.1
 	k8s.io/apimachinery v0.22.1
 	k8s.io/client-go v0.22.1
 	sigs.k8s.io/controller-runtime v0.10.0
+	sigs.k8s.io/yaml v1.2.0
 )
+
+// The storage v1.16.1 required by sigstore cosign doesn't build with the genproto required by the other modules
+require cloud.google.com/go/storage v1.18.2 // indirect
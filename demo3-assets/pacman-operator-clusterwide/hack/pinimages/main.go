@@ -0,0 +1,154 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// pinimages rewrites the related images of the operator manifests from tags to digests. Disconnected clusters
+// mirror the images through ImageContentSourcePolicies, which only apply to images pulled by digest, so the
+// RELATED_IMAGE environment variables of the manager and the relatedImages of the CSV must be pinned:
+//
+//	go run ./hack/pinimages config/manager/manager.yaml config/manifests/bases/pacman-operator.clusterserviceversion.yaml
+//
+// The digests are looked up in the registries with the local docker credentials. With --check the registries
+// aren't queried, it fails if any related image is not pinned by digest.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+var (
+	// An environment variable holding a related image, its value is on the next line
+	relatedImageEnvVar = regexp.MustCompile(`^\s*- name: RELATED_IMAGE_\w+\s*$`)
+	envVarValue        = regexp.MustCompile(`^(\s*value: )(\S+)(\s*)$`)
+	// The CSV relatedImages list and its image fields
+	relatedImagesKey = regexp.MustCompile(`^(\s*)relatedImages:\s*$`)
+	imageField       = regexp.MustCompile(`^(\s*-?\s*image: )(\S+)(\s*)$`)
+)
+
+func main() {
+	var check bool
+	flag.BoolVar(&check, "check", false, "Only check the related images are pinned by digest, without rewriting them.")
+	flag.Parse()
+
+	digests := map[string]string{}
+	resolve := func(image string) (string, error) {
+		if _, err := name.NewDigest(image); err == nil {
+			return image, nil
+		}
+		if check {
+			return "", fmt.Errorf("related image %s is not pinned by digest, run make pin-related-images", image)
+		}
+		if pinned, ok := digests[image]; ok {
+			return pinned, nil
+		}
+		pinned, err := pin(image)
+		if err != nil {
+			return "", err
+		}
+		digests[image] = pinned
+		return pinned, nil
+	}
+
+	failed := false
+	for _, file := range flag.Args() {
+		if err := pinFile(file, check, resolve); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// pin returns a given tagged image pinned to the digest the tag points to in its registry
+func pin(image string) (string, error) {
+	tag, err := name.NewTag(image, name.StrictValidation)
+	if err != nil {
+		return "", fmt.Errorf("invalid related image %s: %w", image, err)
+	}
+	descriptor, err := remote.Head(tag, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", image, err)
+	}
+	// The repository is kept as written, only the tag is replaced
+	return strings.TrimSuffix(image, ":"+tag.TagStr()) + "@" + descriptor.Digest.String(), nil
+}
+
+// pinFile rewrites the related images of a given manifest with the images returned by resolve,
+// the rest of the file is left untouched
+func pinFile(file string, check bool, resolve func(string) (string, error)) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	var errs []string
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	previous := ""
+	inRelatedImages := false
+	relatedImagesIndent := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inRelatedImages && strings.TrimSpace(line) != "" {
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if indent < relatedImagesIndent || (indent == relatedImagesIndent && !strings.HasPrefix(strings.TrimSpace(line), "-")) {
+				inRelatedImages = false
+			}
+		}
+		if match := relatedImagesKey.FindStringSubmatch(line); match != nil {
+			inRelatedImages = true
+			relatedImagesIndent = len(match[1])
+		}
+
+		var match []string
+		switch {
+		case relatedImageEnvVar.MatchString(previous):
+			match = envVarValue.FindStringSubmatch(line)
+		case inRelatedImages:
+			match = imageField.FindStringSubmatch(line)
+		}
+		if match != nil {
+			image, err := resolve(match[2])
+			if err != nil {
+				errs = append(errs, err.Error())
+			} else {
+				line = match[1] + image + match[3]
+			}
+		}
+		out.WriteString(line + "\n")
+		previous = line
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if check || out.String() == string(content) {
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(out.String()), 0644)
+}
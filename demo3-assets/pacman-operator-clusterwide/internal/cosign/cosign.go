@@ -0,0 +1,155 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosign verifies the cosign signatures of images with public keys through the sigstore cosign
+// library. The signatures are read from the registry holding the image and the sigstore transparency log
+// isn't queried, so the verification works in disconnected clusters.
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// ErrVerificationFailed is returned when an image has no valid signature made with a trusted key
+var ErrVerificationFailed = errors.New("signature verification failed")
+
+// PublicKey is a key trusted to sign images
+type PublicKey struct {
+	Name string
+	Key  crypto.PublicKey
+}
+
+// ParsePublicKey parses a PEM encoded public key, like the cosign.pub written by cosign generate-key-pair.
+// ECDSA, RSA and Ed25519 keys are supported
+func ParsePublicKey(name string, data []byte) (PublicKey, error) {
+	key, err := cryptoutils.UnmarshalPEMToPublicKey(data)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key %s: %w", name, err)
+	}
+	if _, err := signature.LoadVerifier(key, crypto.SHA256); err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key %s: %w", name, err)
+	}
+	return PublicKey{Name: name, Key: key}, nil
+}
+
+// Policy requires the images of some repositories to be signed with one of its keys
+type Policy struct {
+	repositories []string
+	keys         []PublicKey
+	client       *registry.Client
+}
+
+// NewPolicy returns a new policy requiring the images of the given repositories to be signed with one of the
+// given keys. The repositories include the registry host, a trailing /* matches every repository under
+// a path, e.g. quay.io/ifont/*, and * matches every repository. The signatures are read with a given registry client
+func NewPolicy(repositories []string, keys []PublicKey, client *registry.Client) (*Policy, error) {
+	if len(repositories) > 0 && len(keys) == 0 {
+		return nil, errors.New("at least one public key is required to verify the signatures")
+	}
+	return &Policy{repositories: repositories, keys: keys, client: client}, nil
+}
+
+// Requires returns true if a given image must be signed, invalid references always require a signature
+func (p *Policy) Requires(image string) bool {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return true
+	}
+	repositoryName := repositoryName(ref.Context())
+	for _, repository := range p.repositories {
+		if repository == "*" || repository == repositoryName ||
+			(strings.HasSuffix(repository, "/*") && strings.HasPrefix(repositoryName, strings.TrimSuffix(repository, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks that a given image pinned by digest has a signature of its digest made with one of the policy
+// keys, it returns the name of the key. The returned error wraps ErrVerificationFailed when no signature is valid
+func (p *Policy) Verify(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.(name.Digest); !ok {
+		return "", fmt.Errorf("%w: image %s is not pinned by digest", ErrVerificationFailed, image)
+	}
+	// The signatures of every key are looked up within the registry client timeout
+	ctx, cancel := p.client.WithTimeout(ctx)
+	defer cancel()
+	var reasons []string
+	for _, key := range p.keys {
+		verifier, err := signature.LoadVerifier(key.Key, crypto.SHA256)
+		if err != nil {
+			return "", fmt.Errorf("invalid public key %s: %w", key.Name, err)
+		}
+		// Without a Rekor URL only the bundles attached to the signatures are checked, the transparency log isn't queried
+		_, err = cosign.Verify(ctx, ref, &cosign.CheckOpts{
+			RegistryClientOpts: p.client.RemoteOptions(ctx, keychain),
+			SigVerifier:        verifier,
+			ClaimVerifier:      cosign.SimpleClaimVerifier,
+		})
+		if err == nil {
+			return key.Name, nil
+		}
+		if isNotFound(err) {
+			return "", fmt.Errorf("%w: image %s is not signed", ErrVerificationFailed, image)
+		}
+		if isRegistryError(err) {
+			return "", err
+		}
+		reasons = append(reasons, fmt.Sprintf("key %s: %v", key.Name, strings.Join(strings.Fields(err.Error()), " ")))
+	}
+	return "", fmt.Errorf("%w: image %s: %s", ErrVerificationFailed, image, strings.Join(reasons, "; "))
+}
+
+// isNotFound returns true if a given error is a registry response for a missing manifest
+func isNotFound(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
+
+// isRegistryError returns true if a given error is a registry or network failure, the verification is retried
+func isRegistryError(err error) bool {
+	var transportErr *transport.Error
+	var urlErr *url.Error
+	return errors.As(err, &transportErr) || errors.As(err, &urlErr) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// repositoryName returns the name of a given repository as written in the policies, Docker Hub repositories
+// are under docker.io
+func repositoryName(repository name.Repository) string {
+	if repository.RegistryStr() == name.DefaultRegistry {
+		return "docker.io/" + repository.RepositoryStr()
+	}
+	return repository.Name()
+}
@@ -0,0 +1,153 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosign_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/mvazquezc/pacman-operator/internal/cosign"
+	"github.com/mvazquezc/pacman-operator/internal/cosign/cosigntest"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/registry/registrytest"
+)
+
+const repository = "ifont/pacman-nodejs-app"
+
+// newPolicy returns a policy requiring a signature with a new key on every repository of a given registry
+func newPolicy(t *testing.T, reg *registrytest.Registry) (*cosign.Policy, *ecdsa.PrivateKey) {
+	key, publicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := cosign.ParsePublicKey("release", publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := cosign.NewPolicy([]string{reg.Host() + "/*"}, []cosign.PublicKey{trusted}, registry.NewClient(nil, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy, key
+}
+
+func TestVerifySignedImage(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	policy, key := newPolicy(t, reg)
+	digest := reg.Push(repository, "v1")
+	if err := cosigntest.Sign(reg, repository, digest, key); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := policy.Verify(context.Background(), reg.Host()+"/"+repository+"@"+digest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "release" {
+		t.Errorf("expected the release key, got %q", name)
+	}
+}
+
+func TestVerifyRejectsUntrustedImages(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	policy, key := newPolicy(t, reg)
+	otherKey, _, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := reg.Push(repository, "unsigned")
+	untrusted := reg.Push(repository, "untrusted")
+	if err := cosigntest.Sign(reg, repository, untrusted, otherKey); err != nil {
+		t.Fatal(err)
+	}
+	// A trusted signature of another image copied onto this one
+	signed := reg.Push(repository, "signed")
+	replayed := reg.Push(repository, "replayed")
+	payload, err := cosigntest.Payload(reg, repository, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cosigntest.SignPayload(reg, repository, replayed, key, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, digest := range map[string]string{"unsigned": unsigned, "untrusted key": untrusted, "replayed signature": replayed} {
+		_, err := policy.Verify(context.Background(), reg.Host()+"/"+repository+"@"+digest, nil)
+		if !errors.Is(err, cosign.ErrVerificationFailed) {
+			t.Errorf("%s: expected a verification failure, got %v", name, err)
+		}
+	}
+	if _, err := policy.Verify(context.Background(), reg.Host()+"/"+repository+":signed", nil); !errors.Is(err, cosign.ErrVerificationFailed) {
+		t.Errorf("expected images not pinned by digest to be rejected, got %v", err)
+	}
+}
+
+// newPublicKey returns a new trusted key named after a given name
+func newPublicKey(t *testing.T, name string) cosign.PublicKey {
+	_, publicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := cosign.ParsePublicKey(name, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRequires(t *testing.T) {
+	policy, err := cosign.NewPolicy([]string{"quay.io/ifont/*", "docker.io/library/mongo"}, []cosign.PublicKey{newPublicKey(t, "release")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for image, expected := range map[string]bool{
+		"quay.io/ifont/pacman-nodejs-app:v1": true,
+		"quay.io/ifont/team/pacman:v1":       true,
+		"quay.io/ifontx/pacman:v1":           false,
+		"mongo:latest":                       true,
+		"quay.io/other/pacman:v1":            false,
+		"not a reference":                    true,
+	} {
+		if actual := policy.Requires(image); actual != expected {
+			t.Errorf("%s: expected %t, got %t", image, expected, actual)
+		}
+	}
+	if _, err := cosign.NewPolicy([]string{"*"}, nil, nil); err == nil {
+		t.Error("expected a policy without keys to be rejected")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	_, publicKey, err := cosigntest.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cosign.ParsePublicKey("release", publicKey); err != nil {
+		t.Error(err)
+	}
+	if _, err := cosign.ParsePublicKey("garbage", []byte("-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n")); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+	if _, err := cosign.ParsePublicKey("empty", nil); err == nil {
+		t.Error("expected a missing key to be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosigntest signs the images of an in-process registry like cosign sign --key does,
+// so the signature verification can be tested offline.
+package cosigntest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/mvazquezc/pacman-operator/internal/registry/registrytest"
+	"github.com/sigstore/cosign/pkg/types"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/payload"
+)
+
+// signatureAnnotation holds the base64 encoded signature of a cosign signature layer
+const signatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// NewKey returns a new ECDSA P-256 signing key, the key type of cosign generate-key-pair, and its PEM encoded
+// public key
+func NewKey() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Payload returns the payload cosign signs for the manifest digest of a given repository of the registry
+func Payload(reg *registrytest.Registry, repository string, digest string) ([]byte, error) {
+	ref, err := name.NewDigest(reg.Host() + "/" + repository + "@" + digest)
+	if err != nil {
+		return nil, err
+	}
+	return payload.Cosign{Image: ref}.MarshalJSON()
+}
+
+// Sign pushes a signature of the manifest digest of a given repository of the registry, signing the payload
+// cosign signs. Every call replaces the signatures of the digest
+func Sign(reg *registrytest.Registry, repository string, digest string, key *ecdsa.PrivateKey) error {
+	content, err := Payload(reg, repository, digest)
+	if err != nil {
+		return err
+	}
+	return SignPayload(reg, repository, digest, key, content)
+}
+
+// SignPayload pushes a signature of a given payload as a signature of the manifest digest of a given repository
+func SignPayload(reg *registrytest.Registry, repository string, digest string, key *ecdsa.PrivateKey, content []byte) error {
+	signer, err := signature.LoadECDSASignerVerifier(key, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignMessage(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	image, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(content, ggcrtypes.MediaType(types.SimpleSigningMediaType)),
+		Annotations: map[string]string{signatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return err
+	}
+	reg.Write(repository+":"+strings.Replace(digest, ":", "-", 1)+".sig", image)
+	return nil
+}
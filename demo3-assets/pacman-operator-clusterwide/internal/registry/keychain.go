@@ -0,0 +1,73 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// dockerConfigKeychain looks up the credentials of a docker config file
+type dockerConfigKeychain struct {
+	config *configfile.ConfigFile
+}
+
+// NewDockerConfigKeychain returns the keychain of a docker config file, like the ones stored in
+// kubernetes.io/dockerconfigjson and kubernetes.io/dockercfg Secrets. legacy is true for the .dockercfg format
+// that doesn't nest the registries under auths. Credential helpers are ignored, the operator can't run them
+func NewDockerConfigKeychain(data []byte, legacy bool) (authn.Keychain, error) {
+	config := configfile.New("")
+	load := config.LoadFromReader
+	if legacy {
+		load = config.LegacyLoadFromReader
+	}
+	if err := load(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to decode docker config: %w", err)
+	}
+	config.CredentialsStore = ""
+	config.CredentialHelpers = nil
+	return &dockerConfigKeychain{config: config}, nil
+}
+
+// Resolve implements authn.Keychain
+func (k *dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	keys := []string{target.RegistryStr()}
+	if target.RegistryStr() == name.DefaultRegistry {
+		// Docker Hub credentials are stored under its legacy index URL or its short name
+		keys = append(keys, authn.DefaultAuthKey, "docker.io")
+	}
+	for _, key := range keys {
+		config, err := k.config.GetAuthConfig(key)
+		if err != nil {
+			return nil, err
+		}
+		if config.Username == "" && config.Password == "" && config.IdentityToken == "" && config.RegistryToken == "" {
+			continue
+		}
+		return authn.FromConfig(authn.AuthConfig{
+			Username:      config.Username,
+			Password:      config.Password,
+			IdentityToken: config.IdentityToken,
+			RegistryToken: config.RegistryToken,
+		}), nil
+	}
+	return authn.Anonymous, nil
+}
@@ -0,0 +1,106 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry resolves image tags to digests with go-containerregistry, so the operator can run every
+// replica of a game from the same build.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// ErrManifestUnknown is returned when the requested tag doesn't exist in the repository
+var ErrManifestUnknown = errors.New("manifest unknown")
+
+// Resolver resolves image references to the digest of their manifest
+type Resolver interface {
+	// Digest returns the digest a given image reference points to, references pinned by digest are returned as is.
+	// The returned error wraps ErrManifestUnknown when the tag doesn't exist
+	Digest(ctx context.Context, image string, keychain authn.Keychain) (string, error)
+}
+
+// Client resolves digests through the OCI distribution API of the registries
+type Client struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+// NewClient returns a new registry client sending its requests through a given transport,
+// remote.DefaultTransport when nil. Every image lookup fails once it takes longer than a given timeout,
+// a zero timeout leaves them unbounded
+func NewClient(transport http.RoundTripper, timeout time.Duration) *Client {
+	if transport == nil {
+		transport = remote.DefaultTransport
+	}
+	return &Client{transport: transport, timeout: timeout}
+}
+
+// WithTimeout returns a context bounding an image lookup with the client timeout, and the function releasing it
+func (c *Client) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// RemoteOptions returns the go-containerregistry options sending requests through the client transport,
+// authenticated with a given keychain, anonymous when nil
+func (c *Client) RemoteOptions(ctx context.Context, keychain authn.Keychain) []remote.Option {
+	return []remote.Option{remote.WithContext(ctx), remote.WithTransport(c.transport), remote.WithAuthFromKeychain(orAnonymous(keychain))}
+}
+
+// Digest returns the digest a given image reference points to
+func (c *Client) Digest(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if digest, ok := ref.(name.Digest); ok {
+		return digest.DigestStr(), nil
+	}
+	ctx, cancel := c.WithTimeout(ctx)
+	defer cancel()
+	digest, err := crane.Digest(image, crane.WithContext(ctx), crane.WithTransport(c.transport), crane.WithAuthFromKeychain(orAnonymous(keychain)))
+	if err != nil && ctx.Err() != nil {
+		// The registry ping errors don't wrap the context error
+		return "", fmt.Errorf("failed to get the manifest of %s: %w", image, ctx.Err())
+	} else if err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("failed to get the manifest of %s: %w", image, ErrManifestUnknown)
+		}
+		return "", fmt.Errorf("failed to get the manifest of %s: %w", image, err)
+	}
+	return digest, nil
+}
+
+// orAnonymous returns a given keychain, or a keychain without credentials when nil
+func orAnonymous(keychain authn.Keychain) authn.Keychain {
+	if keychain == nil {
+		return authn.NewMultiKeychain()
+	}
+	return keychain
+}
@@ -0,0 +1,200 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/registry/registrytest"
+)
+
+func TestDigestFollowsFloatingTags(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	client := registry.NewClient(nil, 0)
+	image := reg.Host() + "/ifont/pacman-nodejs-app:latest"
+
+	first := reg.Push("ifont/pacman-nodejs-app", "latest")
+	digest, err := client.Digest(context.Background(), image, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != first {
+		t.Errorf("expected %s, got %s", first, digest)
+	}
+
+	second := reg.Push("ifont/pacman-nodejs-app", "latest")
+	if digest, err = client.Digest(context.Background(), image, nil); err != nil {
+		t.Fatal(err)
+	}
+	if digest != second {
+		t.Errorf("expected the moved tag to resolve to %s, got %s", second, digest)
+	}
+}
+
+func TestDigestOfPinnedImageSkipsRegistry(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	pinned := reg.Push("ifont/pacman-nodejs-app", "v1")
+	requests := reg.Requests()
+
+	digest, err := registry.NewClient(nil, 0).Digest(context.Background(), reg.Host()+"/ifont/pacman-nodejs-app@"+pinned, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != pinned || reg.Requests() != requests {
+		t.Errorf("expected %s without requests, got %s after %d requests", pinned, digest, reg.Requests()-requests)
+	}
+}
+
+func TestDigestComputedFromManifest(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	expected := reg.Push("ifont/pacman-nodejs-app", "v1")
+	reg.OmitDigestHeader = true
+
+	digest, err := registry.NewClient(nil, 0).Digest(context.Background(), reg.Host()+"/ifont/pacman-nodejs-app:v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected {
+		t.Errorf("expected %s, got %s", expected, digest)
+	}
+}
+
+func TestDigestWithPullSecret(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	reg.RequireAuth("pacman", "s3cr3t")
+	expected := reg.Push("team/pacman-fork", "v2")
+	client := registry.NewClient(nil, 0)
+	image := reg.Host() + "/team/pacman-fork:v2"
+
+	if _, err := client.Digest(context.Background(), image, nil); err == nil {
+		t.Fatal("expected the resolution to fail without credentials")
+	}
+
+	keychain, err := registry.NewDockerConfigKeychain(reg.DockerConfigJSON("pacman", "s3cr3t"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := client.Digest(context.Background(), image, keychain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected {
+		t.Errorf("expected %s, got %s", expected, digest)
+	}
+}
+
+func TestDigestOfUnknownTag(t *testing.T) {
+	reg := registrytest.NewRegistry()
+	defer reg.Close()
+	reg.Push("ifont/pacman-nodejs-app", "v1")
+
+	for _, image := range []string{"/ifont/pacman-nodejs-app:v9", "/ifont/missing:v1"} {
+		_, err := registry.NewClient(nil, 0).Digest(context.Background(), reg.Host()+image, nil)
+		if !errors.Is(err, registry.ErrManifestUnknown) {
+			t.Errorf("%s: expected a manifest unknown error, got %v", image, err)
+		}
+	}
+}
+
+func TestDigestOfInvalidReference(t *testing.T) {
+	for _, image := range []string{"quay.io/ifont/Pacman:v1", "quay.io/ifont/pacman:v1!", "quay.io/pacman@sha256:xyz", ""} {
+		if _, err := registry.NewClient(nil, 0).Digest(context.Background(), image, nil); err == nil || errors.Is(err, registry.ErrManifestUnknown) {
+			t.Errorf("%q: expected an invalid reference error, got %v", image, err)
+		}
+	}
+}
+
+func TestDockerConfigKeychain(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("player:one"))
+	keychain, err := registry.NewDockerConfigKeychain([]byte(fmt.Sprintf(`{"https://index.docker.io/v1/":{"auth":%q}}`, auth)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for image, expected := range map[string]authn.AuthConfig{
+		"mongo":                              {Username: "player", Password: "one"},
+		"docker.io/percona/mongodb_exporter": {Username: "player", Password: "one"},
+		"quay.io/ifont/pacman-nodejs-app":    {},
+	} {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authenticator, err := keychain.Resolve(ref.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := authenticator.Authorization()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *config != expected {
+			t.Errorf("%s: expected %+v, got %+v", image, expected, *config)
+		}
+	}
+}
+
+func TestDockerConfigKeychainIgnoresCredentialHelpers(t *testing.T) {
+	keychain, err := registry.NewDockerConfigKeychain([]byte(`{"credsStore":"desktop","credHelpers":{"quay.io":"ecr-login"}}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference("quay.io/ifont/pacman-nodejs-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := keychain.Resolve(ref.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authenticator != authn.Anonymous {
+		t.Errorf("expected anonymous access, got %v", authenticator)
+	}
+}
+
+func TestDigestTimeout(t *testing.T) {
+	// The registry never answers
+	reg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer reg.Close()
+	image := strings.TrimPrefix(reg.URL, "http://") + "/ifont/pacman-nodejs-app:v1"
+
+	start := time.Now()
+	_, err := registry.NewClient(nil, 100*time.Millisecond).Digest(context.Background(), image, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the lookup to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the lookup to stop after the timeout, it took %s", elapsed)
+	}
+}
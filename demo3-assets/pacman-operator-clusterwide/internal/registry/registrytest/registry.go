@@ -0,0 +1,146 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registrytest serves an in-process go-containerregistry registry and pushes random images to it,
+// so the digest resolution can be tested without a real registry.
+package registrytest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Registry is an in-process registry served over plain HTTP on the loopback interface,
+// which go-containerregistry talks to without TLS
+type Registry struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handler  http.Handler
+	username string
+	password string
+	requests int
+	// OmitDigestHeader stops returning the Docker-Content-Digest header, like some registries do
+	OmitDigestHeader bool
+}
+
+// NewRegistry returns a new started registry, it must be closed once done
+func NewRegistry() *Registry {
+	r := &Registry{handler: registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// Host returns the host:port of the registry, used as the registry of the image references
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+// RequireAuth makes the registry require the given basic auth credentials
+func (r *Registry) RequireAuth(username string, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.username, r.password = username, password
+}
+
+// Requests returns the number of manifest requests served
+func (r *Registry) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+// Push pushes a new random image to a given repository and points the tag to it, it returns the manifest digest
+func (r *Registry) Push(repository string, tag string) string {
+	image, err := random.Image(256, 1)
+	if err != nil {
+		panic(err)
+	}
+	return r.Write(repository+":"+tag, image)
+}
+
+// Write pushes a given image to a repository:tag of the registry, it returns the manifest digest
+func (r *Registry) Write(reference string, image v1.Image) string {
+	ref, err := name.ParseReference(r.Host() + "/" + reference)
+	if err != nil {
+		panic(err)
+	}
+	r.mu.Lock()
+	username, password := r.username, r.password
+	r.mu.Unlock()
+	options := []remote.Option{}
+	if username != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{Username: username, Password: password}))
+	}
+	if err := remote.Write(ref, image, options...); err != nil {
+		panic(err)
+	}
+	digest, err := image.Digest()
+	if err != nil {
+		panic(err)
+	}
+	return digest.String()
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	username, password, omitDigestHeader := r.username, r.password, r.OmitDigestHeader
+	if strings.Contains(req.URL.Path, "/manifests/") {
+		r.requests++
+	}
+	r.mu.Unlock()
+
+	if username != "" {
+		if u, p, ok := req.BasicAuth(); !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registrytest"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if omitDigestHeader && req.Method == http.MethodHead {
+		w = &omitDigestWriter{ResponseWriter: w}
+	}
+	r.handler.ServeHTTP(w, req)
+}
+
+// omitDigestWriter drops the Docker-Content-Digest header of the responses
+type omitDigestWriter struct {
+	http.ResponseWriter
+}
+
+func (w *omitDigestWriter) WriteHeader(statusCode int) {
+	w.Header().Del("Docker-Content-Digest")
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// DockerConfigJSON returns a kubernetes.io/dockerconfigjson content holding the given credentials for the registry
+func (r *Registry) DockerConfigJSON(username string, password string) []byte {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return []byte(fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, r.Host(), auth))
+}
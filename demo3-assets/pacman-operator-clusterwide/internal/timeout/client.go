@@ -0,0 +1,114 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timeout bounds the duration of the API calls made by the operator,
+// so a slow or unreachable API server can't block a reconcile indefinitely.
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Client wraps a controller-runtime client giving every API call its own deadline
+type Client struct {
+	client.Client
+	timeout time.Duration
+}
+
+// NewClient returns a client failing the API calls that take longer than a given timeout,
+// a zero timeout returns c unchanged
+func NewClient(c client.Client, timeout time.Duration) client.Client {
+	if timeout <= 0 {
+		return c
+	}
+	return &Client{Client: c, timeout: timeout}
+}
+
+// Get calls client.Get with the call deadline
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Get(ctx, key, obj)
+}
+
+// List calls client.List with the call deadline
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.List(ctx, list, opts...)
+}
+
+// Create calls client.Create with the call deadline
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update calls client.Update with the call deadline
+func (c *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch calls client.Patch with the call deadline
+func (c *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete calls client.Delete with the call deadline
+func (c *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// DeleteAllOf calls client.DeleteAllOf with the call deadline
+func (c *Client) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Status returns a status writer giving every status write its own deadline
+func (c *Client) Status() client.StatusWriter {
+	return &statusWriter{StatusWriter: c.Client.Status(), timeout: c.timeout}
+}
+
+type statusWriter struct {
+	client.StatusWriter
+	timeout time.Duration
+}
+
+// Update calls the status Update with the call deadline
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+// Patch calls the status Patch with the call deadline
+func (w *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
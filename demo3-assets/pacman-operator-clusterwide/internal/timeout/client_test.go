@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timeout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mvazquezc/pacman-operator/internal/timeout"
+)
+
+// hangingClient is an API client whose calls only return once their context is done
+type hangingClient struct {
+	client.Client
+}
+
+func (c *hangingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (c *hangingClient) Status() client.StatusWriter {
+	return &hangingStatusWriter{}
+}
+
+type hangingStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w *hangingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCallExceedingTimeout(t *testing.T) {
+	c := timeout.NewClient(&hangingClient{Client: fake.NewClientBuilder().Build()}, 50*time.Millisecond)
+
+	start := time.Now()
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: "pacman", Name: "game"}, &corev1.ConfigMap{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("expected the call to stop after the timeout, it took %s", elapsed)
+	}
+
+	err = c.Status().Patch(context.Background(), &corev1.ConfigMap{}, client.MergeFrom(&corev1.ConfigMap{}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the status write to time out, got %v", err)
+	}
+}
+
+func TestParentDeadlineIsRespected(t *testing.T) {
+	c := timeout.NewClient(&hangingClient{Client: fake.NewClientBuilder().Build()}, time.Minute)
+
+	// The reconcile deadline is shorter than the call timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.Get(ctx, client.ObjectKey{Namespace: "pacman", Name: "game"}, &corev1.ConfigMap{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to stop at the parent deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the call to stop at the parent deadline, it took %s", elapsed)
+	}
+}
+
+func TestZeroTimeout(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	if timeout.NewClient(c, 0) != c {
+		t.Errorf("expected a zero timeout to return the client unchanged")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Client wraps a controller-runtime client recording a span for every API call
+type Client struct {
+	client.Client
+}
+
+// NewClient returns a client recording a span for every API call made through c
+func NewClient(c client.Client) *Client {
+	return &Client{Client: c}
+}
+
+// Get records a span around client.Get
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	ctx, span := c.startSpan(ctx, "Get", obj, key.Namespace, key.Name)
+	defer span.End()
+	err := c.Client.Get(ctx, key, obj)
+	RecordError(span, err)
+	return err
+}
+
+// List records a span around client.List
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	ctx, span := c.startSpan(ctx, "List", list, listOpts.Namespace, "")
+	defer span.End()
+	err := c.Client.List(ctx, list, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Create records a span around client.Create
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	ctx, span := c.startSpan(ctx, "Create", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	err := c.Client.Create(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Update records a span around client.Update
+func (c *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, span := c.startSpan(ctx, "Update", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	err := c.Client.Update(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Patch records a span around client.Patch
+func (c *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, span := c.startSpan(ctx, "Patch", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	span.SetAttributes(attribute.String("k8s.patch_type", string(patch.Type())))
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Delete records a span around client.Delete
+func (c *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	ctx, span := c.startSpan(ctx, "Delete", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	err := c.Client.Delete(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Status returns a status writer recording a span for every status write
+func (c *Client) Status() client.StatusWriter {
+	return &statusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type statusWriter struct {
+	client.StatusWriter
+	client *Client
+}
+
+// Update records a span around the status Update
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, span := w.client.startSpan(ctx, "Status.Update", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	err := w.StatusWriter.Update(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// Patch records a span around the status Patch
+func (w *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, span := w.client.startSpan(ctx, "Status.Patch", obj, obj.GetNamespace(), obj.GetName())
+	defer span.End()
+	err := w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	RecordError(span, err)
+	return err
+}
+
+// startSpan starts the span of an API call, named after the call and the kind of the object
+func (c *Client) startSpan(ctx context.Context, operation string, obj runtime.Object, namespace string, name string) (context.Context, trace.Span) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	attributes := []attribute.KeyValue{attribute.String("k8s.operation", operation), attribute.String("k8s.kind", kind)}
+	if namespace != "" {
+		attributes = append(attributes, attribute.String("k8s.namespace", namespace))
+	}
+	if name != "" {
+		attributes = append(attributes, attribute.String("k8s.name", name))
+	}
+	return StartSpan(ctx, operation+" "+kind, attributes...)
+}
@@ -0,0 +1,106 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// OTLP/HTTP path of the traces endpoint
+	tracesPath = "/v1/traces"
+	// The spans still queued when the operator stops are exported within this timeout
+	shutdownTimeout = 5 * time.Second
+)
+
+// NewProvider returns a TracerProvider batching the spans of a given service to an OTLP/HTTP collector endpoint,
+// e.g. http://otel-collector:4318. The traces started by the operator are sampled with a given ratio,
+// the ones propagated by a caller keep the caller sampling decision
+func NewProvider(ctx context.Context, endpoint string, serviceName string, samplingRatio float64) (*sdktrace.TracerProvider, error) {
+	if samplingRatio < 0 || samplingRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sampling ratio %v, expected a value between 0 and 1", samplingRatio)
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Host == "" || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") {
+		return nil, fmt.Errorf("invalid tracing endpoint %q, expected an http or https URL", endpoint)
+	}
+	path := strings.TrimSuffix(endpointURL.Path, "/")
+	if !strings.HasSuffix(path, tracesPath) {
+		path += tracesPath
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpointURL.Host), otlptracehttp.WithURLPath(path)}
+	if endpointURL.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES can add attributes such as the pod name
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+	), nil
+}
+
+// ShutdownOnStop returns a manager.Runnable shutting a given TracerProvider down when the manager stops,
+// so the queued spans are exported
+func ShutdownOnStop(provider *sdktrace.TracerProvider) manager.Runnable {
+	return &shutdownRunnable{shutdown: provider.Shutdown}
+}
+
+type shutdownRunnable struct {
+	shutdown func(context.Context) error
+}
+
+// Start waits until ctx is done, then calls shutdown
+func (r *shutdownRunnable) Start(ctx context.Context) error {
+	<-ctx.Done()
+	// The manager context is already cancelled, give the last export its own deadline
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return r.shutdown(shutdownCtx)
+}
+
+// NeedLeaderElection returns false so spans are exported by every operator replica
+func (r *shutdownRunnable) NeedLeaderElection() bool {
+	return false
+}
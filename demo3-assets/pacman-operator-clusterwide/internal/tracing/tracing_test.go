@@ -0,0 +1,220 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupRecorder sets a global TracerProvider recording the spans in memory
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func endedSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not ended, got %v", name, recorder.Ended())
+	return nil
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestStartSpan(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	ctx, parent := StartSpan(context.Background(), "parent", attribute.String("pacmangame.name", "pacman"))
+	_, child := StartSpan(ctx, "child", attribute.Int64("pacmangame.generation", 3))
+	RecordError(child, errors.New("apply failed"))
+	child.End()
+	RecordError(parent, nil)
+	parent.End()
+
+	parentSpan := endedSpan(t, recorder, "parent")
+	childSpan := endedSpan(t, recorder, "child")
+	if parentSpan.Parent().IsValid() {
+		t.Errorf("expected a root span, got parent %s", parentSpan.Parent().SpanID())
+	}
+	if childSpan.Parent().SpanID() != parentSpan.SpanContext().SpanID() || childSpan.SpanContext().TraceID() != parentSpan.SpanContext().TraceID() {
+		t.Errorf("expected child of %s, got %s", parentSpan.SpanContext().SpanID(), childSpan.Parent().SpanID())
+	}
+	if got := attributeValue(parentSpan, "pacmangame.name").AsString(); got != "pacman" {
+		t.Errorf("expected pacmangame.name pacman, got %q", got)
+	}
+	if got := attributeValue(childSpan, "pacmangame.generation").AsInt64(); got != 3 {
+		t.Errorf("expected pacmangame.generation 3, got %d", got)
+	}
+	if status := childSpan.Status(); status.Code != codes.Error || status.Description != "apply failed" {
+		t.Errorf("expected error status, got %+v", status)
+	}
+	if status := parentSpan.Status(); status.Code != codes.Unset {
+		t.Errorf("expected unset status, got %+v", status)
+	}
+}
+
+func TestClientSpans(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "pacman", Namespace: "games"}}
+	tracedClient := NewClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(configMap).Build())
+
+	ctx, parent := StartSpan(context.Background(), "reconcile")
+	if err := tracedClient.Get(ctx, types.NamespacedName{Name: "pacman", Namespace: "games"}, &corev1.ConfigMap{}); err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+	if err := tracedClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "games"}}); err == nil {
+		t.Fatal("expected the Secret deletion to fail")
+	}
+	parent.End()
+
+	parentSpan := endedSpan(t, recorder, "reconcile")
+	getSpan := endedSpan(t, recorder, "Get ConfigMap")
+	if getSpan.Parent().SpanID() != parentSpan.SpanContext().SpanID() {
+		t.Errorf("expected Get to be a child of the reconcile span")
+	}
+	if got := attributeValue(getSpan, "k8s.name").AsString(); got != "pacman" {
+		t.Errorf("expected k8s.name pacman, got %q", got)
+	}
+	if status := endedSpan(t, recorder, "Delete Secret").Status(); status.Code != codes.Error {
+		t.Errorf("expected the failed Delete to have an error status, got %+v", status)
+	}
+}
+
+// collector is an in-process OTLP/HTTP collector keeping the spans it receives
+type collector struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	spans  []*tracev1.ResourceSpans
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != tracesPath || req.Header.Get("Content-Type") != "application/x-protobuf" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		request := &collectortracev1.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.spans = append(c.spans, request.ResourceSpans...)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		response, _ := proto.Marshal(&collectortracev1.ExportTraceServiceResponse{})
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+func TestProviderExportsOnStop(t *testing.T) {
+	c := newCollector(t)
+	provider, err := NewProvider(context.Background(), c.server.URL, "pacman-operator-test", 1)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ShutdownOnStop(provider).Start(ctx) }()
+
+	_, span := provider.Tracer(instrumentationName).Start(context.Background(), "reconcile")
+	span.End()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("failed to export spans on stop: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 1 {
+		t.Fatalf("expected the spans of one resource, got %v", c.spans)
+	}
+	serviceName := ""
+	for _, kv := range c.spans[0].Resource.Attributes {
+		if kv.Key == string(semconv.ServiceNameKey) {
+			serviceName = kv.Value.GetStringValue()
+		}
+	}
+	if serviceName != "pacman-operator-test" {
+		t.Errorf("expected service.name pacman-operator-test, got %q", serviceName)
+	}
+	spans := c.spans[0].InstrumentationLibrarySpans
+	if len(spans) != 1 || len(spans[0].Spans) != 1 || spans[0].Spans[0].Name != "reconcile" {
+		t.Errorf("expected the reconcile span, got %v", spans)
+	}
+}
+
+func TestNewProviderErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		endpoint      string
+		samplingRatio float64
+	}{
+		"missing scheme":      {endpoint: "otel-collector:4318", samplingRatio: 1},
+		"grpc scheme":         {endpoint: "grpc://otel-collector:4317", samplingRatio: 1},
+		"sampling ratio over": {endpoint: "http://otel-collector:4318", samplingRatio: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewProvider(context.Background(), tc.endpoint, "pacman-operator-test", tc.samplingRatio); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
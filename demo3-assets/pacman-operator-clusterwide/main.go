@@ -17,26 +17,56 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	configv1alpha1 "github.com/mvazquezc/pacman-operator/api/config/v1alpha1"
 	appsv1beta1 "github.com/mvazquezc/pacman-operator/api/v1beta1"
 	"github.com/mvazquezc/pacman-operator/controllers"
+	"github.com/mvazquezc/pacman-operator/internal/cosign"
+	"github.com/mvazquezc/pacman-operator/internal/registry"
+	"github.com/mvazquezc/pacman-operator/internal/timeout"
+	"github.com/mvazquezc/pacman-operator/internal/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
+// pacmanGameGroupKind is the key of the PacmanGame controller concurrency in the manager configuration
+var pacmanGameGroupKind = schema.GroupKind{Group: appsv1beta1.GroupVersion.Group, Kind: "PacmanGame"}
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -50,14 +80,64 @@ func init() {
 }
 
 func main() {
+	var configFile string
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var enableClusterRBAC bool
+	var tracingEndpoint string
+	var tracingSamplingRatio float64
+	var apiCallTimeout time.Duration
+	var registryTimeout time.Duration
+	var cacheManagedObjectsOnly bool
+	var maxConcurrentReconciles int
+	var backoffBaseDelay time.Duration
+	var backoffMaxDelay time.Duration
+	var syncPeriod time.Duration
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var namespaceSelector string
+	var resolveImageDigests bool
+	flag.StringVar(&configFile, "config", "",
+		"The operator configuration file. The flags given in the command line override its values.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableClusterRBAC, "enable-cluster-rbac", true,
+		"Allow PacmanGames to run in Cluster RBAC scope. "+
+			"Requires the operator to be granted the manager-cluster-role permissions.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "",
+		"OTLP/HTTP collector endpoint the reconcile traces are exported to, e.g. http://otel-collector:4318. "+
+			"Tracing is disabled when empty.")
+	flag.Float64Var(&tracingSamplingRatio, "tracing-sampling-ratio", 1,
+		"Ratio of the reconcile traces exported to the tracing endpoint, between 0 and 1.")
+	flag.DurationVar(&apiCallTimeout, "api-call-timeout", 30*time.Second,
+		"Maximum duration of each Kubernetes API call made while reconciling. "+
+			"Calls exceeding it fail and the reconcile is retried, 0 disables the timeout.")
+	flag.DurationVar(&registryTimeout, "registry-timeout", 30*time.Second,
+		"Maximum duration of each Pacman image lookup in its registry, its signature verification included. "+
+			"Lookups exceeding it fail and the reconcile is retried, 0 disables the timeout.")
+	flag.BoolVar(&cacheManagedObjectsOnly, "cache-managed-objects-only", true,
+		"Only cache the objects labelled as managed by the operator. "+
+			"Disabling it caches every watched object in the cluster, it's only meant to compare the operator memory usage.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"Maximum number of PacmanGames reconciled at the same time.")
+	flag.DurationVar(&backoffBaseDelay, "reconcile-backoff-base-delay", 5*time.Millisecond,
+		"Delay before retrying a failed reconcile, doubled on each consecutive failure of the same PacmanGame.")
+	flag.DurationVar(&backoffMaxDelay, "reconcile-backoff-max-delay", 1000*time.Second,
+		"Maximum delay before retrying a failed reconcile.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour,
+		"Minimum frequency at which every PacmanGame is reconciled even if nothing changed.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20, "Maximum queries per second sent to the Kubernetes API server.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30, "Maximum burst of queries sent to the Kubernetes API server.")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "",
+		"Only reconcile the PacmanGames in namespaces matching this label selector, e.g. pacman.rha.lab/enabled=true. "+
+			"Every watched namespace is reconciled when empty.")
+	flag.BoolVar(&resolveImageDigests, "resolve-image-digests", true,
+		"Resolve the Pacman image tags to digests through the image registry and deploy the games by digest. "+
+			"The games are deployed by tag when disabled, the tags are still checked to exist before being rolled out.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -65,25 +145,129 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
-	watchNamespace, _ := getWatchNamespace()
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// The configuration file provides the values of the flags not given in the command line
+	var operatorConfig *configv1alpha1.ControllerManagerConfig
+	// The images mirrored for disconnected installs replace the built-in ones
+	defaults := controllers.NewDefaults().Override(controllers.DefaultsFromRelatedImages(os.LookupEnv))
+	for _, envVar := range []string{controllers.RelatedImagePacmanEnvVar, controllers.RelatedImageMongoEnvVar, controllers.RelatedImageMongoExporterEnvVar} {
+		if image := os.Getenv(envVar); image != "" && !strings.Contains(image, "@sha256:") {
+			setupLog.Info("related image not pinned by digest, it won't be pulled from the disconnected mirrors", "variable", envVar, "image", image)
+		}
+	}
+	if configFile != "" {
+		var err error
+		operatorConfig, err = configv1alpha1.LoadFile(configFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load the configuration file", "file", configFile)
+			os.Exit(1)
+		}
+		if err := applyConfigFlags(flag.CommandLine, operatorConfig); err != nil {
+			setupLog.Error(err, "unable to apply the configuration file", "file", configFile)
+			os.Exit(1)
+		}
+		defaults = defaults.Override(defaultsFromConfig(operatorConfig.GameDefaults))
+	}
+
+	watchNamespaces, err := getWatchNamespaces()
+	if errors.Is(err, errWatchNamespaceNotSet) {
+		setupLog.Info("watching every namespace", "reason", err.Error())
+	} else if err != nil {
+		setupLog.Error(err, "invalid watch namespaces")
+		os.Exit(1)
+	}
+	selector, err := parseNamespaceSelector(namespaceSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid namespace selector", "selector", namespaceSelector)
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+
+	managerOptions := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "8fe6888c.rha.lab",
-		Namespace:              watchNamespace,
-	})
+		SyncPeriod:             &syncPeriod,
+		Controller: cfg.ControllerConfigurationSpec{
+			GroupKindConcurrency: map[string]int{
+				pacmanGameGroupKind.String(): maxConcurrentReconciles,
+			},
+		},
+		// Secrets and ConfigMaps are read straight from the API server instead of being cached
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
+	}
+	// A single namespace is handled by the default cache, several of them need a cache per namespace
+	if len(watchNamespaces) == 1 {
+		managerOptions.Namespace = watchNamespaces[0]
+	}
+	managerOptions.NewCache = newCache(watchNamespaces, cacheManagedObjectsOnly)
+	// The manager settings without a flag, like the leader election leases, come straight from the file
+	if operatorConfig != nil {
+		managerOptions, err = managerOptions.AndFrom(operatorConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to apply the configuration file", "file", configFile)
+			os.Exit(1)
+		}
+	}
+	if managerOptions.Port == 0 {
+		managerOptions.Port = 9443
+	}
+	if managerOptions.LeaderElectionID == "" {
+		managerOptions.LeaderElectionID = "8fe6888c.rha.lab"
+	}
+	mgr, err := ctrl.NewManager(restConfig, managerOptions)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// Spans are only recorded when a collector has been configured
+	if tracingEndpoint != "" {
+		tracingProvider, err := tracing.NewProvider(context.Background(), tracingEndpoint, "pacman-operator", tracingSamplingRatio)
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+		if err := mgr.Add(tracing.ShutdownOnStop(tracingProvider)); err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+		otel.SetTracerProvider(tracingProvider)
+		setupLog.Info("exporting traces", "endpoint", tracingEndpoint, "samplingRatio", tracingSamplingRatio)
+	}
+	// The trace context is propagated to the registries with the W3C Trace Context headers
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// The Pacman image tags are always checked to exist, they're only pinned by digest when resolveImageDigests is set
+	registryClient := registry.NewClient(otelhttp.NewTransport(remote.DefaultTransport), registryTimeout)
+	// Images requiring a signature are only verified when pinned by digest
+	var imagePolicy *cosign.Policy
+	var signedRepositories []string
+	if operatorConfig != nil && operatorConfig.Operator.ImageVerification != nil {
+		imagePolicy, err = newImagePolicy(operatorConfig.Operator.ImageVerification, registryClient)
+		if err != nil {
+			setupLog.Error(err, "invalid image verification policy", "file", configFile)
+			os.Exit(1)
+		}
+		signedRepositories = operatorConfig.Operator.ImageVerification.Repositories
+	}
+
 	if err = (&controllers.PacmanGameReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              tracing.NewClient(timeout.NewClient(mgr.GetClient(), apiCallTimeout)),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("pacmangame-controller"),
+		Cache:               mgr.GetCache(),
+		ClusterRBACEnabled:  enableClusterRBAC,
+		RateLimiter:         newReconcileRateLimiter(backoffBaseDelay, backoffMaxDelay),
+		NamespaceSelector:   selector,
+		Defaults:            defaults,
+		Registry:            registryClient,
+		ResolveImageDigests: resolveImageDigests,
+		ImagePolicy:         imagePolicy,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PacmanGame")
 		os.Exit(1)
@@ -99,23 +283,186 @@ func main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting manager")
+	setupLog.Info("starting manager",
+		"maxConcurrentReconciles", maxConcurrentReconciles,
+		"reconcileBackoffBaseDelay", backoffBaseDelay.String(),
+		"reconcileBackoffMaxDelay", backoffMaxDelay.String(),
+		"syncPeriod", syncPeriod.String(),
+		"kubeAPIQPS", restConfig.QPS,
+		"kubeAPIBurst", restConfig.Burst,
+		"apiCallTimeout", apiCallTimeout.String(),
+		"registryTimeout", registryTimeout.String(),
+		"watchNamespaces", watchNamespaces,
+		"namespaceSelector", selector.String(),
+		"resolveImageDigests", resolveImageDigests,
+		"signedRepositories", signedRepositories,
+		"configFile", configFile,
+		"pacmanImageRepository", defaults.PacmanImageRepository,
+		"mongoImage", defaults.MongoImage)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
-// getWatchNamespace returns the Namespace the operator should be watching for changes
-func getWatchNamespace() (string, error) {
+// errWatchNamespaceNotSet is returned when WATCH_NAMESPACE isn't set, the operator then runs with cluster scope
+var errWatchNamespaceNotSet = errors.New("WATCH_NAMESPACE is not set")
+
+// getWatchNamespaces returns the Namespaces the operator should be watching for changes
+func getWatchNamespaces() ([]string, error) {
 	// WatchNamespaceEnvVar is the constant for env variable WATCH_NAMESPACE
-	// which specifies the Namespace to watch.
+	// which specifies a comma separated list of Namespaces to watch.
 	// An empty value means the operator is running with cluster scope.
 	var watchNamespaceEnvVar = "WATCH_NAMESPACE"
 
-	ns, found := os.LookupEnv(watchNamespaceEnvVar)
+	value, found := os.LookupEnv(watchNamespaceEnvVar)
 	if !found {
-		return "", fmt.Errorf("%s must be set", watchNamespaceEnvVar)
+		return nil, errWatchNamespaceNotSet
+	}
+	var namespaces []string
+	for _, ns := range strings.Split(value, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return nil, fmt.Errorf("%s contains an invalid namespace %q: %s", watchNamespaceEnvVar, ns, strings.Join(errs, ", "))
+		}
+		namespaces = append(namespaces, ns)
 	}
-	return ns, nil
+	return namespaces, nil
+}
+
+// parseNamespaceSelector parses the --namespace-selector flag, the empty selector selects every namespace
+func parseNamespaceSelector(value string) (labels.Selector, error) {
+	return labels.Parse(value)
+}
+
+// newCache returns the manager cache for the watched namespaces. When managedObjectsOnly is set the cache
+// only holds the objects labelled as managed by the operator, instead of every object of the watched kinds.
+// PacmanGames, HorizontalPodAutoscalers and Namespaces aren't created by the operator, so they are cached in full
+func newCache(namespaces []string, managedObjectsOnly bool) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if managedObjectsOnly {
+			managed := labels.SelectorFromSet(labels.Set{controllers.ManagedByLabel: controllers.FieldManager})
+			opts.SelectorsByObject = cache.SelectorsByObject{
+				&appsv1.Deployment{}:         {Label: managed},
+				&corev1.Service{}:            {Label: managed},
+				&corev1.ServiceAccount{}:     {Label: managed},
+				&corev1.ConfigMap{}:          {Label: managed},
+				&corev1.Pod{}:                {Label: managed},
+				&rbacv1.Role{}:               {Label: managed},
+				&rbacv1.RoleBinding{}:        {Label: managed},
+				&rbacv1.ClusterRole{}:        {Label: managed},
+				&rbacv1.ClusterRoleBinding{}: {Label: managed},
+			}
+		}
+		if len(namespaces) > 1 {
+			return cache.MultiNamespacedCacheBuilder(namespaces)(config, opts)
+		}
+		return cache.New(config, opts)
+	}
+}
+
+// applyConfigFlags sets the flags not given in the command line to the values of the configuration file
+func applyConfigFlags(fs *flag.FlagSet, config *configv1alpha1.ControllerManagerConfig) error {
+	givenFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		givenFlags[f.Name] = true
+	})
+	for name, value := range configFlagValues(config) {
+		if givenFlags[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for flag %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// configFlagValues returns the values the configuration file sets, keyed by the name of their flag
+func configFlagValues(config *configv1alpha1.ControllerManagerConfig) map[string]string {
+	values := map[string]string{}
+	setString := func(name string, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			values[name] = strconv.FormatBool(*value)
+		}
+	}
+	setDuration := func(name string, value *metav1.Duration) {
+		if value != nil {
+			values[name] = value.Duration.String()
+		}
+	}
+	setString("metrics-bind-address", config.Metrics.BindAddress)
+	setString("health-probe-bind-address", config.Health.HealthProbeBindAddress)
+	if config.LeaderElection != nil {
+		setBool("leader-elect", config.LeaderElection.LeaderElect)
+	}
+	setDuration("sync-period", config.SyncPeriod)
+	if config.Controller != nil {
+		if concurrency, ok := config.Controller.GroupKindConcurrency[pacmanGameGroupKind.String()]; ok {
+			values["max-concurrent-reconciles"] = strconv.Itoa(concurrency)
+		}
+	}
+
+	operator := config.Operator
+	setBool("enable-cluster-rbac", operator.EnableClusterRBAC)
+	setString("tracing-endpoint", operator.TracingEndpoint)
+	if operator.TracingSamplingRatio != nil {
+		values["tracing-sampling-ratio"] = strconv.FormatFloat(float64(*operator.TracingSamplingRatio), 'f', -1, 32)
+	}
+	setDuration("api-call-timeout", operator.APICallTimeout)
+	setBool("cache-managed-objects-only", operator.CacheManagedObjectsOnly)
+	setDuration("reconcile-backoff-base-delay", operator.ReconcileBackoffBaseDelay)
+	setDuration("reconcile-backoff-max-delay", operator.ReconcileBackoffMaxDelay)
+	if operator.KubeAPIQPS != nil {
+		values["kube-api-qps"] = strconv.FormatFloat(float64(*operator.KubeAPIQPS), 'f', -1, 32)
+	}
+	if operator.KubeAPIBurst != nil {
+		values["kube-api-burst"] = strconv.Itoa(*operator.KubeAPIBurst)
+	}
+	setString("namespace-selector", operator.NamespaceSelector)
+	setBool("resolve-image-digests", operator.ResolveImageDigests)
+	return values
+}
+
+// newImagePolicy returns the policy requiring the signatures set in the configuration file
+func newImagePolicy(verification *configv1alpha1.ImageVerification, client *registry.Client) (*cosign.Policy, error) {
+	keys := make([]cosign.PublicKey, 0, len(verification.PublicKeys))
+	for _, publicKey := range verification.PublicKeys {
+		key, err := cosign.ParsePublicKey(publicKey.Name, []byte(publicKey.Key))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return cosign.NewPolicy(verification.Repositories, keys, client)
+}
+
+// defaultsFromConfig returns the game defaults set in the configuration file
+func defaultsFromConfig(gameDefaults configv1alpha1.GameDefaults) controllers.Defaults {
+	return controllers.Defaults{
+		PacmanImageRepository: gameDefaults.PacmanImageRepository,
+		MongoImage:            gameDefaults.MongoImage,
+		MongoExporterImage:    gameDefaults.MongoExporterImage,
+		PacmanReplicas:        gameDefaults.PacmanReplicas,
+		PacmanResources:       gameDefaults.PacmanResources,
+		MongoResources:        gameDefaults.MongoResources,
+		PacmanServiceType:     gameDefaults.PacmanServiceType,
+	}
+}
+
+// newReconcileRateLimiter returns the workqueue rate limiter of the PacmanGame controller, it matches the
+// controller-runtime default except for the configurable per item exponential backoff
+func newReconcileRateLimiter(baseDelay time.Duration, maxDelay time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
 }
@@ -0,0 +1,108 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestGetWatchNamespaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   *string
+		want    []string
+		wantErr bool
+	}{
+		{name: "not set", value: nil, want: nil},
+		{name: "empty", value: stringPtr(""), want: nil},
+		{name: "single namespace", value: stringPtr("pacman"), want: []string{"pacman"}},
+		{name: "several namespaces", value: stringPtr("pacman, games ,,arcade"), want: []string{"pacman", "games", "arcade"}},
+		{name: "invalid namespace", value: stringPtr("pacman,Games"), wantErr: true},
+	}
+	previous, found := os.LookupEnv("WATCH_NAMESPACE")
+	defer func() {
+		if found {
+			os.Setenv("WATCH_NAMESPACE", previous)
+		} else {
+			os.Unsetenv("WATCH_NAMESPACE")
+		}
+	}()
+	for _, test := range tests {
+		if test.value == nil {
+			os.Unsetenv("WATCH_NAMESPACE")
+		} else {
+			os.Setenv("WATCH_NAMESPACE", *test.value)
+		}
+		got, err := getWatchNamespaces()
+		if test.value == nil {
+			if !errors.Is(err, errWatchNamespaceNotSet) {
+				t.Errorf("%s: expected errWatchNamespaceNotSet, got %v", test.name, err)
+			}
+			continue
+		}
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestParseNamespaceSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		labels  labels.Set
+		empty   bool
+		matches bool
+		wantErr bool
+	}{
+		{name: "empty selector", value: "", labels: labels.Set{"team": "arcade"}, empty: true, matches: true},
+		{name: "matching selector", value: "team=arcade", labels: labels.Set{"team": "arcade"}, matches: true},
+		{name: "non-matching selector", value: "team=arcade", labels: labels.Set{"team": "puzzle"}, matches: false},
+		{name: "missing label", value: "team=arcade", labels: labels.Set{}, matches: false},
+		{name: "set based selector", value: "team in (arcade,retro),!legacy", labels: labels.Set{"team": "retro"}, matches: true},
+		{name: "invalid selector", value: "team=(arcade", wantErr: true},
+	}
+	for _, test := range tests {
+		selector, err := parseNamespaceSelector(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if selector.Empty() != test.empty {
+			t.Errorf("%s: expected empty %v, got %v", test.name, test.empty, selector.Empty())
+		}
+		if got := selector.Matches(test.labels); got != test.matches {
+			t.Errorf("%s: expected matches %v, got %v", test.name, test.matches, got)
+		}
+	}
+}
+
+func stringPtr(value string) *string {
+	return &value
+}